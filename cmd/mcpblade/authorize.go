@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+func authorizeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "authorize",
+		Usage:     "Print the URL completing the OAuth authorization of a remote server",
+		ArgsUsage: "<server_id>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "URL of the HTTP transport of the running service",
+				Value: "http://localhost:8080",
+			},
+		},
+		Action: authorize,
+	}
+}
+
+// authorize asks the running service for an authorization URL, as the
+// pending authorization lives in the service until its callback.
+func authorize(ctx context.Context, cmd *cli.Command) error {
+	serverID := cmd.Args().First()
+	if serverID == "" {
+		return errors.New("server id is required")
+	}
+
+	endpoint := strings.TrimSuffix(cmd.String("api"), "/") +
+		"/api/mcp/oauth/authorize/" + url.PathEscape(serverID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return errors.New("authorize " + serverID + ": " + strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	fmt.Println("Open the following URL to authorize " + serverID + ":")
+	fmt.Println(result.AuthorizationURL)
+	return nil
+}
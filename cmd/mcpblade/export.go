@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/flarexio/mcpblade"
+)
+
+func exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export the persistent MCP servers as the mcp.json of Claude Desktop or VS Code",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Format of the mcp.json (claude, vscode)",
+				Value: string(mcpblade.ConfigFormatClaudeDesktop),
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Write to the file, keeping its other settings, instead of stdout",
+			},
+		},
+		Action: export,
+	}
+}
+
+func export(ctx context.Context, cmd *cli.Command) error {
+	format := mcpblade.ConfigFormat(cmd.String("format"))
+	switch format {
+	case mcpblade.ConfigFormatClaudeDesktop, mcpblade.ConfigFormatVSCode:
+
+	default:
+		return errors.New("unsupported format: " + string(format))
+	}
+
+	path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := mcpblade.LoadConfig(configPath(cmd, path))
+	if err != nil {
+		return err
+	}
+
+	// Servers over NATS cannot be described by an mcp.json
+	servers := maps.Clone(cfg.MCPServers)
+	for id, config := range servers {
+		if _, err := mcpblade.NewMCPJSONServer(config, format); err != nil {
+			fmt.Fprintf(os.Stderr, "skipped %s: %s\n", id, err.Error())
+			delete(servers, id)
+		}
+	}
+
+	output := cmd.String("output")
+	if output == "" {
+		data, err := mcpblade.ExportMCPJSON(nil, servers, format)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	data, err = mcpblade.ExportMCPJSON(data, servers, format)
+	if err != nil {
+		return err
+	}
+
+	// The file keeps its mode, and a new one is readable by the owner only, as
+	// the env and headers of the servers may hold secrets
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(output); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	return mcpblade.WriteFileAtomic(output, data, mode)
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"log"
+	"maps"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -15,7 +17,6 @@ import (
 	"github.com/nats-io/nats.go/micro"
 	"github.com/urfave/cli/v3"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v3"
 
 	"github.com/flarexio/mcpblade"
 	"github.com/flarexio/mcpblade/persistence/chromem"
@@ -34,6 +35,10 @@ func main() {
 				Name:  "path",
 				Usage: "Path to the MCPBlade service",
 			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to the config file, or to the mcp.json of Claude Desktop or VS Code (default: <path>/config.yaml)",
+			},
 			&cli.StringFlag{
 				Name:    "nats",
 				Usage:   "NATS server URL",
@@ -50,6 +55,24 @@ func main() {
 				Usage: "HTTP server address",
 				Value: ":8080",
 			},
+			&cli.BoolFlag{
+				Name:  "http-lazy",
+				Usage: "List only the search_tools, describe_tool and invoke_tool meta-tools on the HTTP MCP endpoint",
+				Value: false,
+			},
+			&cli.StringSliceFlag{
+				Name:  "ws-allowed-origins",
+				Usage: "Origins of web pages allowed to connect to the WebSocket transport besides the same host and localhost, or * for any",
+			},
+			&cli.StringFlag{
+				Name:  "oauth-redirect-uri",
+				Usage: "Redirect URI of the OAuth authorizations of remote servers (default with --http: http://localhost<http-addr>/api/mcp/oauth/callback)",
+			},
+		},
+		Commands: []*cli.Command{
+			authorizeCommand(),
+			exportCommand(),
+			validateCommand(),
 		},
 		Action: run,
 	}
@@ -60,17 +83,56 @@ func main() {
 	}
 }
 
-func run(ctx context.Context, cmd *cli.Command) error {
+// servicePath returns the path to the MCPBlade service.
+func servicePath(cmd *cli.Command) (string, error) {
 	path := cmd.String("path")
 	if path == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		path = filepath.Join(homeDir, ".flarex", "mcpblade")
 	}
 
+	return path, nil
+}
+
+// configPath returns the path to the config file.
+func configPath(cmd *cli.Command, path string) string {
+	if config := cmd.String("config"); config != "" {
+		return config
+	}
+
+	return filepath.Join(path, "config.yaml")
+}
+
+// redirectURI returns the redirect URI of the OAuth authorizations, served by
+// the HTTP transport. Without --http there is no default, as nothing would
+// receive the callback; the callback is then completed over NATS instead.
+func redirectURI(cmd *cli.Command) string {
+	if uri := cmd.String("oauth-redirect-uri"); uri != "" {
+		return uri
+	}
+
+	if !cmd.Bool("http") {
+		return ""
+	}
+
+	port := strings.TrimPrefix(cmd.String("http-addr"), ":")
+	if _, p, err := net.SplitHostPort(cmd.String("http-addr")); err == nil {
+		port = p
+	}
+
+	return "http://localhost:" + port + "/api/mcp/oauth/callback"
+}
+
+func run(ctx context.Context, cmd *cli.Command) error {
+	path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+
 	log, err := zap.NewDevelopment()
 	if err != nil {
 		return err
@@ -79,14 +141,14 @@ func run(ctx context.Context, cmd *cli.Command) error {
 
 	zap.ReplaceGlobals(log)
 
-	f, err := os.Open(filepath.Join(path, "config.yaml"))
+	configPath := configPath(cmd, path)
+
+	cfg, err := mcpblade.LoadConfig(configPath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	var cfg mcpblade.Config
-	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+	if err := cfg.Validate(); err != nil {
 		return err
 	}
 
@@ -97,44 +159,73 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	svc, err := mcpblade.NewService(ctx, cfg, vector)
+	natsURL := cmd.String("nats")
+	natsCreds := filepath.Join(path, "user.creds")
+
+	idBytes, err := os.ReadFile(filepath.Join(path, "id"))
 	if err != nil {
 		return err
 	}
-	defer svc.Close()
 
-	svc = mcpblade.LoggingMiddleware(log)(svc)
+	edgeID := strings.TrimSpace(string(idBytes))
 
-	endpoints := mcpblade.EndpointSet{
-		RegisterMCPServer:   mcpblade.RegisterMCPServerEndpoint(svc),
-		UnregisterMCPServer: mcpblade.UnregisterMCPServerEndpoint(svc),
-		ListTools:           mcpblade.ListToolsEndpoint(svc),
-		SearchTools:         mcpblade.SearchToolsEndpoint(svc),
-		Forward:             mcpblade.ForwardEndpoint(svc),
+	nc, err := nats.Connect(natsURL,
+		nats.Name("MCPBlade Server - "+edgeID),
+		nats.UserCredentials(natsCreds),
+	)
+
+	if err != nil {
+		return err
 	}
+	defer nc.Drain()
 
-	natsURL := cmd.String("nats")
-	natsCreds := filepath.Join(path, "user.creds")
+	svc, err := mcpblade.NewService(ctx, cfg, vector,
+		mcpblade.WithTransport(mcpblade.TransportTypeNATS, natsT.NewTransportFactory(nc)),
+		mcpblade.WithOAuth(filepath.Join(path, "oauth"), redirectURI(cmd)),
+		mcpblade.WithConfigSaver(func(servers map[string]mcpblade.MCPServerConfig) error {
+			return mcpblade.SaveMCPServers(configPath, servers)
+		}),
+	)
 
-	idBytes, err := os.ReadFile(filepath.Join(path, "id"))
 	if err != nil {
 		return err
 	}
+	defer svc.Close()
 
-	// Add NATS Transport
-	{
-		edgeID := strings.TrimSpace(string(idBytes))
+	svc = mcpblade.LoggingMiddleware(log)(svc)
 
-		nc, err := nats.Connect(natsURL,
-			nats.Name("MCPBlade Server - "+edgeID),
-			nats.UserCredentials(natsCreds),
-		)
+	endpoints := mcpblade.EndpointSet{
+		RegisterMCPServer:     mcpblade.RegisterMCPServerEndpoint(svc),
+		UpdateMCPServer:       mcpblade.UpdateMCPServerEndpoint(svc),
+		UnregisterMCPServer:   mcpblade.UnregisterMCPServerEndpoint(svc),
+		Heartbeat:             mcpblade.HeartbeatEndpoint(svc),
+		AuthorizeMCPServer:    mcpblade.AuthorizeMCPServerEndpoint(svc),
+		CompleteAuthorization: mcpblade.CompleteAuthorizationEndpoint(svc),
+		ListTools:             mcpblade.ListToolsEndpoint(svc),
+		SearchTools:           mcpblade.SearchToolsEndpoint(svc),
+		Forward:               mcpblade.ForwardEndpoint(svc),
+
+		ListResources:         mcpblade.ListResourcesEndpoint(svc),
+		ListResourceTemplates: mcpblade.ListResourceTemplatesEndpoint(svc),
+		ReadResource:          mcpblade.ReadResourceEndpoint(svc),
+
+		ListPrompts: mcpblade.ListPromptsEndpoint(svc),
+		GetPrompt:   mcpblade.GetPromptEndpoint(svc),
+	}
 
-		if err != nil {
-			return err
-		}
-		defer nc.Drain()
+	mcpEndpoints := make(map[mcp.MCPMethod]mcpE.MCPEndpoint)
+	mcpEndpoints[mcp.MethodInitialize] = mcpE.InitializeEndpoint(svc)
+	mcpEndpoints[mcp.MethodPing] = mcpE.PingEndpoint(svc)
+	mcpEndpoints[mcp.MethodToolsList] = mcpE.ListToolsEndpoint(svc)
+	mcpEndpoints[mcp.MethodToolsCall] = mcpE.CallToolEndpoint(svc)
+	mcpEndpoints[mcp.MethodResourcesList] = mcpE.ListResourcesEndpoint(svc)
+	mcpEndpoints[mcp.MethodResourcesTemplatesList] = mcpE.ListResourceTemplatesEndpoint(svc)
+	mcpEndpoints[mcp.MethodResourcesRead] = mcpE.ReadResourceEndpoint(svc)
+	mcpEndpoints[mcp.MethodPromptsList] = mcpE.ListPromptsEndpoint(svc)
+	mcpEndpoints[mcp.MethodPromptsGet] = mcpE.GetPromptEndpoint(svc)
 
+	// Add NATS Transport
+	{
 		srv, err := micro.AddService(nc, micro.Config{
 			Name:    "mcpblade",
 			Version: "1.0.0",
@@ -149,6 +240,17 @@ func run(ctx context.Context, cmd *cli.Command) error {
 
 		root := srv.AddGroup(topic)
 		natsT.AddEndpoints(root, endpoints)
+		natsT.AddMCPEndpoint(root, mcpEndpoints)
+
+		svc.OnNotification(natsT.NotificationPublisher(nc, topic+".notifications"))
+
+		// Notifications of the aggregated servers for nats backend clients
+		publish := natsT.NotificationPublisher(nc, topic+".mcp.notifications")
+		svc.OnNotification(func(serverID string, notification mcp.JSONRPCNotification) {
+			if serverID == "" {
+				publish(serverID, notification)
+			}
+		})
 	}
 
 	httpEnabled := cmd.Bool("http")
@@ -156,22 +258,57 @@ func run(ctx context.Context, cmd *cli.Command) error {
 		r := gin.Default()
 		httpT.AddRouters(r, endpoints)
 
-		endpoints := make(map[mcp.MCPMethod]mcpE.MCPEndpoint)
-		endpoints[mcp.MethodInitialize] = mcpE.InitializeEndpoint(svc)
-		endpoints[mcp.MethodPing] = mcpE.PingEndpoint(svc)
-		endpoints[mcp.MethodToolsList] = mcpE.ListToolsEndpoint(svc)
-		endpoints[mcp.MethodToolsCall] = mcpE.CallToolEndpoint(svc)
-		httpT.AddStreamableRouters(r, endpoints)
+		// Notifications for the MCP sessions of the HTTP transports
+		sessions := httpT.NewSessions()
+		defer sessions.Close()
+
+		svc.OnNotification(sessions.Broadcast)
+
+		origins := cmd.StringSlice("ws-allowed-origins")
+
+		if cmd.Bool("http-lazy") {
+			lazyEndpoints := maps.Clone(mcpEndpoints)
+			lazyEndpoints[mcp.MethodInitialize] = mcpE.InitializeEndpoint(svc, true)
+			lazyEndpoints[mcp.MethodToolsList] = mcpE.ListToolsEndpoint(svc, true)
+			lazyEndpoints[mcp.MethodToolsCall] = mcpE.CallToolEndpoint(svc, true)
+			httpT.AddStreamableRouters(r, lazyEndpoints, sessions)
+			httpT.AddSSERouters(r, lazyEndpoints, sessions)
+			httpT.AddWebSocketRouters(r, lazyEndpoints, sessions, origins...)
+		} else {
+			httpT.AddStreamableRouters(r, mcpEndpoints, sessions)
+			httpT.AddSSERouters(r, mcpEndpoints, sessions)
+			httpT.AddWebSocketRouters(r, mcpEndpoints, sessions, origins...)
+		}
 
 		httpAddr := cmd.String("http-addr")
 		go r.Run(httpAddr)
 	}
 
+	// Reload the MCP servers when the config changes, or on SIGHUP
+	reload := make(chan struct{}, 1)
+	if err := watchConfig(ctx, configPath, reload); err != nil {
+		log.Warn("config watcher disabled", zap.Error(err))
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	sign := <-quit
+	for {
+		select {
+		case sign := <-quit:
+			log.Info("graceful shutdown", zap.String("signal", sign.String()))
+			return nil
+
+		case sign := <-hup:
+			log.Info("reload config", zap.String("signal", sign.String()))
+			reloadConfig(ctx, svc, configPath)
 
-	log.Info("graceful shutdown", zap.String("signal", sign.String()))
-	return nil
+		case <-reload:
+			log.Info("reload config", zap.String("reason", "config changed"))
+			reloadConfig(ctx, svc, configPath)
+		}
+	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"github.com/flarexio/mcpblade"
+)
+
+// reloadDebounce coalesces the events of a single save, as editors often
+// write, truncate and rename the file in quick succession.
+const reloadDebounce = 500 * time.Millisecond
+
+// watchConfig signals reload when the config file changes. The directory is
+// watched rather than the file itself, so that a file replaced by a rename,
+// e.g. by an editor or an atomic write-back, keeps being watched.
+func watchConfig(ctx context.Context, path string, reload chan<- struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	log := zap.L().With(
+		zap.String("action", "watch_config"),
+		zap.String("path", path),
+	)
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != filepath.Clean(path) {
+					continue
+				}
+
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+
+				debounce = time.After(reloadDebounce)
+
+			case <-debounce:
+				debounce = nil
+
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				log.Error(err.Error())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reloadConfig reconciles the persistent MCP servers with the config file.
+// An invalid config is logged and leaves the running servers as they are.
+func reloadConfig(ctx context.Context, svc mcpblade.Service, path string) {
+	log := zap.L().With(
+		zap.String("action", "reload_config"),
+		zap.String("path", path),
+	)
+
+	cfg, err := mcpblade.LoadConfig(path)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	// The summary is logged by the logging middleware
+	if _, err := svc.ReloadMCPServers(ctx, cfg.MCPServers); err != nil {
+		log.Error(err.Error())
+	}
+}
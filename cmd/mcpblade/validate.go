@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/flarexio/mcpblade"
+)
+
+func validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "validate",
+		Usage:  "Validate the config file and report all its problems",
+		Action: validate,
+	}
+}
+
+func validate(ctx context.Context, cmd *cli.Command) error {
+	path, err := servicePath(cmd)
+	if err != nil {
+		return err
+	}
+
+	configPath := configPath(cmd, path)
+
+	cfg, err := mcpblade.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Validate()
+
+	var errs mcpblade.ValidationErrors
+	if !errors.As(err, &errs) {
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(configPath + ": OK")
+		return nil
+	}
+
+	// One problem per line, as file:line:column: field: message
+	for _, e := range errs {
+		location := configPath
+		if e.Line > 0 {
+			location += ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Column)
+		}
+
+		fmt.Fprintln(os.Stderr, location+": "+e.Field+": "+e.Message)
+	}
+
+	return cli.Exit("", 1)
+}
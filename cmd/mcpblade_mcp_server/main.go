@@ -11,7 +11,9 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/nats-io/nats.go"
@@ -26,20 +28,98 @@ import (
 type StdioMCPServer interface {
 	AddEndpoint(method mcp.MCPMethod, endpoint mcpE.MCPEndpoint) error
 	Listen(ctx context.Context) error
+	Notify(notification mcp.JSONRPCNotification) error
 }
 
-func NewStdioMCPServer() StdioMCPServer {
+// DefaultMaxInflight is the default number of requests processed concurrently.
+const DefaultMaxInflight = 8
+
+// NewStdioMCPServer returns a server processing up to maxInflight requests
+// concurrently, or DefaultMaxInflight if not set.
+func NewStdioMCPServer(maxInflight ...int) StdioMCPServer {
+	n := DefaultMaxInflight
+	if len(maxInflight) > 0 && maxInflight[0] > 0 {
+		n = maxInflight[0]
+	}
+
+	return newStdioMCPServer(os.Stdin, os.Stdout, n)
+}
+
+func newStdioMCPServer(in io.Reader, out io.Writer, maxInflight int) *stdioMCPServer {
 	return &stdioMCPServer{
-		endpoints: make(map[mcp.MCPMethod]mcpE.MCPEndpoint),
+		endpoints:   make(map[mcp.MCPMethod]mcpE.MCPEndpoint),
+		inflight:    mcpE.NewInflightRequests(),
+		maxInflight: maxInflight,
+		in:          in,
+		encoder:     json.NewEncoder(out),
 	}
 }
 
 type stdioMCPServer struct {
-	endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint
+	endpoints   map[mcp.MCPMethod]mcpE.MCPEndpoint
+	inflight    *mcpE.InflightRequests
+	maxInflight int
+
+	in      io.Reader
+	encoder *json.Encoder
+	writeMu sync.Mutex
+}
+
+// write writes a single JSON-RPC message as one line to stdout.
+func (s *stdioMCPServer) write(msg any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.encoder.Encode(msg)
+}
+
+func (s *stdioMCPServer) Notify(notification mcp.JSONRPCNotification) error {
+	return s.write(notification)
+}
+
+// handle processes a message or batch and writes its response, if any.
+// Responses may be written out of order, as they are matched to the requests
+// by ID.
+func (s *stdioMCPServer) handle(ctx context.Context, dispatcher *mcpE.Dispatcher, line string) {
+	// Progress of the request is written to stdout before the response
+	ctx = context.WithValue(ctx, mcpblade.Progress, mcpblade.ProgressReporter(func(notification mcp.JSONRPCNotification) {
+		s.Notify(notification)
+	}))
+
+	resp := dispatcher.Dispatch(ctx, []byte(line))
+	if resp == nil {
+		return
+	}
+
+	s.write(resp)
 }
 
 func (s *stdioMCPServer) Listen(ctx context.Context) error {
-	scanner := bufio.NewScanner(os.Stdin)
+	scanner := bufio.NewScanner(s.in)
+
+	dispatcher := mcpE.NewDispatcher(s.endpoints, s.inflight)
+
+	// Requests are processed by a bounded pool of workers, so that a slow
+	// request does not block the others
+	requests := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.maxInflight; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for line := range requests {
+				s.handle(ctx, dispatcher, line)
+			}
+		}()
+	}
+
+	defer func() {
+		close(requests)
+		wg.Wait()
+	}()
 
 	lines := make(chan string)
 	errs := make(chan error, 1)
@@ -48,8 +128,14 @@ func (s *stdioMCPServer) Listen(ctx context.Context) error {
 		defer close(lines)
 
 		for scanner.Scan() {
+			line := scanner.Text()
+
+			if dispatcher.Cancel([]byte(line)) {
+				continue
+			}
+
 			select {
-			case lines <- scanner.Text():
+			case lines <- line:
 			case <-ctx.Done():
 				return
 			}
@@ -77,45 +163,15 @@ func (s *stdioMCPServer) Listen(ctx context.Context) error {
 				return nil
 			}
 
-			if line == "" {
+			if strings.TrimSpace(line) == "" {
 				continue
 			}
 
-			var req mcpE.JSONRPCRequest
-			if err := json.Unmarshal([]byte(line), &req); err != nil {
-				continue
-			}
-
-			if req.ID.IsNil() {
-				continue
-			}
-
-			var resp mcp.JSONRPCMessage
-
-			endpoint, ok := s.endpoints[req.Method]
-			if !ok {
-				resp = mcp.JSONRPCError{
-					JSONRPC: mcp.JSONRPC_VERSION,
-					ID:      req.ID,
-					Error: struct {
-						Code    int    `json:"code"`
-						Message string `json:"message"`
-						Data    any    `json:"data,omitempty"`
-					}{
-						Code:    mcp.METHOD_NOT_FOUND,
-						Message: "method not found",
-					},
-				}
-			}
-
-			resp = endpoint(ctx, req)
-
-			bs, err := json.Marshal(resp)
-			if err != nil {
-				continue
+			select {
+			case requests <- line:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
-
-			fmt.Fprintf(os.Stdout, "%s\n", bs)
 		}
 	}
 }
@@ -130,6 +186,32 @@ func (srv *stdioMCPServer) AddEndpoint(method mcp.MCPMethod, endpoint mcpE.MCPEn
 	return nil
 }
 
+// keepAlive sends heartbeats for the dedicated MCP server so that it is not
+// reaped, and registers it again if the service has already expired it.
+func keepAlive(ctx context.Context, svc mcpblade.Service, serverID string, cfg mcpblade.MCPServerConfig) {
+	ticker := time.NewTicker(cfg.TTL.Duration() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			err := svc.Heartbeat(ctx, serverID)
+			if err == nil {
+				continue
+			}
+
+			log.Println("heartbeat failed: " + err.Error())
+
+			if err := svc.RegisterMCPServer(ctx, serverID, cfg); err != nil {
+				log.Println("re-register failed: " + err.Error())
+			}
+		}
+	}
+}
+
 func main() {
 	cmd := &cli.Command{
 		Name:  "mcpblade_mcp_server",
@@ -159,6 +241,21 @@ func main() {
 				Name:  "cmd",
 				Usage: "Command to run for the MCP server",
 			},
+			&cli.BoolFlag{
+				Name:  "lazy",
+				Usage: "List only the search_tools, describe_tool and invoke_tool meta-tools",
+				Value: false,
+			},
+			&cli.IntFlag{
+				Name:  "max-inflight",
+				Usage: "Maximum number of requests processed concurrently",
+				Value: DefaultMaxInflight,
+			},
+			&cli.DurationFlag{
+				Name:  "ttl",
+				Usage: "Time to live of the dedicated MCP server without heartbeats",
+				Value: mcpblade.DefaultTTL,
+			},
 		},
 		ArgsUsage: "[command and arguments...]",
 		Action:    run,
@@ -208,9 +305,15 @@ func run(ctx context.Context, cmd *cli.Command) error {
 			return errors.New("no command provided for MCP server")
 		}
 
+		ttl := cmd.Duration("ttl")
+		if ttl <= 0 {
+			ttl = mcpblade.DefaultTTL
+		}
+
 		cfg := mcpblade.MCPServerConfig{
 			Transport: mcpblade.TransportTypeStdio,
 			Command:   commandArgs[0],
+			TTL:       mcpblade.Duration(ttl),
 		}
 
 		if len(commandArgs) > 1 {
@@ -222,22 +325,56 @@ func run(ctx context.Context, cmd *cli.Command) error {
 			return err
 		}
 
-		defer svc.UnregisterMCPServer(ctx, serverID)
+		// Unregistered once the context is cancelled, which stops keepAlive
+		defer svc.UnregisterMCPServer(context.WithoutCancel(ctx), serverID)
+
+		go keepAlive(ctx, svc, serverID, cfg)
 	}
 
-	s := NewStdioMCPServer()
-	s.AddEndpoint(mcp.MethodInitialize, mcpE.InitializeEndpoint(svc))
+	s := NewStdioMCPServer(int(cmd.Int("max-inflight")))
+	lazy := cmd.Bool("lazy")
+
+	s.AddEndpoint(mcp.MethodInitialize, mcpE.InitializeEndpoint(svc, lazy))
 	s.AddEndpoint(mcp.MethodPing, mcpE.PingEndpoint(svc))
-	s.AddEndpoint(mcp.MethodToolsList, mcpE.ListToolsEndpoint(svc))
-	s.AddEndpoint(mcp.MethodToolsCall, mcpE.CallToolEndpoint(svc))
+	s.AddEndpoint(mcp.MethodToolsList, mcpE.ListToolsEndpoint(svc, lazy))
+	s.AddEndpoint(mcp.MethodToolsCall, mcpE.CallToolEndpoint(svc, lazy))
+	s.AddEndpoint(mcp.MethodResourcesList, mcpE.ListResourcesEndpoint(svc))
+	s.AddEndpoint(mcp.MethodResourcesTemplatesList, mcpE.ListResourceTemplatesEndpoint(svc))
+	s.AddEndpoint(mcp.MethodResourcesRead, mcpE.ReadResourceEndpoint(svc))
+	s.AddEndpoint(mcp.MethodPromptsList, mcpE.ListPromptsEndpoint(svc))
+	s.AddEndpoint(mcp.MethodPromptsGet, mcpE.GetPromptEndpoint(svc))
+
+	// Relay notifications for the shared servers, or for the dedicated server
+	notifications := topic + ".notifications"
+	sub, err := natsT.SubscribeNotifications(nc, notifications, func(id string, notification mcp.JSONRPCNotification) {
+		if id != serverID {
+			return
+		}
+
+		s.Notify(notification)
+	})
+
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
 
-	go s.Listen(ctx)
+	// Serve until the client closes stdin, or a signal is received
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Listen(ctx)
+	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 
-	<-quit
+	select {
+	case <-quit:
+		cancel()
+		return nil
 
-	cancel()
-	return nil
+	case err := <-done:
+		cancel()
+		return err
+	}
 }
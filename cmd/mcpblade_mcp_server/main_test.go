@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+// newTestStdioServer starts a server whose tools/call blocks until release is
+// closed, and returns the writer of its input and its responses by ID.
+func newTestStdioServer(t *testing.T, maxInflight int, release <-chan struct{}) (io.Writer, <-chan string) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	s := newStdioMCPServer(inR, outW, maxInflight)
+
+	s.AddEndpoint(mcp.MethodPing, func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+		return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: req.ID, Result: struct{}{}}
+	})
+
+	s.AddEndpoint(mcp.MethodToolsCall, func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+
+		return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: req.ID, Result: struct{}{}}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go s.Listen(ctx)
+
+	ids := make(chan string, 8)
+
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var resp struct {
+				ID json.RawMessage `json:"id"`
+			}
+
+			if err := json.Unmarshal(scanner.Bytes(), &resp); err == nil {
+				ids <- string(resp.ID)
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		outW.Close()
+	})
+
+	return inW, ids
+}
+
+func request(id int, method string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s"}`+"\n", id, method)
+}
+
+func next(ids <-chan string) string {
+	select {
+	case id := <-ids:
+		return id
+	case <-time.After(time.Second):
+		return ""
+	}
+}
+
+func TestStdioMCPServerConcurrency(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	in, ids := newTestStdioServer(t, 2, release)
+
+	// A blocked request does not delay the next one, whose response is
+	// written first
+	io.WriteString(in, request(1, "tools/call"))
+	io.WriteString(in, request(2, "ping"))
+
+	assert.Equal("2", next(ids))
+
+	close(release)
+
+	assert.Equal("1", next(ids))
+}
+
+func TestStdioMCPServerBound(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	in, ids := newTestStdioServer(t, 1, release)
+
+	// With a single worker, the next request waits for the blocked one
+	io.WriteString(in, request(1, "tools/call"))
+	io.WriteString(in, request(2, "ping"))
+
+	select {
+	case id := <-ids:
+		assert.Fail("unexpected response " + id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Cancellations are handled while all workers are busy
+	io.WriteString(in, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`+"\n")
+
+	assert.Equal("2", next(ids))
+
+	close(release)
+}
+
+func TestStdioMCPServerEOF(t *testing.T) {
+	assert := assert.New(t)
+
+	inR, inW := io.Pipe()
+
+	s := newStdioMCPServer(inR, io.Discard, 1)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Listen(context.Background())
+	}()
+
+	// Listen returns once the client closes stdin
+	io.WriteString(inW, request(1, "ping"))
+	inW.Close()
+
+	select {
+	case err := <-done:
+		assert.NoError(err)
+	case <-time.After(time.Second):
+		assert.Fail("listen did not return on EOF")
+	}
+}
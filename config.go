@@ -0,0 +1,198 @@
+package mcpblade
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSaver persists the persistent MCP servers after they are changed at
+// runtime.
+type ConfigSaver func(servers map[string]MCPServerConfig) error
+
+// WithConfigSaver sets how persistent MCP servers registered, updated or
+// unregistered at runtime are saved. Without it, changes last until the
+// service is closed.
+func WithConfigSaver(save ConfigSaver) ServiceOption {
+	return func(svc *service) {
+		svc.saveConfig = save
+	}
+}
+
+// LoadConfig reads a config file, either a YAML config, or the mcp.json of
+// Claude Desktop or VS Code, which only sets the MCP servers. Settings left
+// unset get their defaults, and variables in the embedding settings are
+// expanded, see Interpolate.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	format := DetectConfigFormat(path, data)
+	if format != ConfigFormatMCPBlade {
+		servers, err := ParseMCPJSON(data, format)
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg.MCPServers = servers
+		cfg.applyDefaults()
+
+		return cfg, nil
+	}
+
+	// A JSON config may have comments like an mcp.json file
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data = stripJSONC(data)
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+
+	// The MCP servers are interpolated when connecting, see
+	// MCPServerConfig.Interpolate
+	embedding := &cfg.Vector.Embedding
+	for _, value := range []*string{&embedding.BaseURL, &embedding.APIKey} {
+		if *value, err = Interpolate(*value); err != nil {
+			return cfg, err
+		}
+	}
+
+	cfg.applyDefaults()
+
+	return cfg, nil
+}
+
+// applyDefaults sets the settings a config file leaves unset, e.g. all but
+// the servers of an mcp.json file.
+func (cfg *Config) applyDefaults() {
+	if cfg.Vector.Collection == "" {
+		cfg.Vector.Collection = DefaultVectorCollection
+	}
+}
+
+// SaveMCPServers replaces the mcpServers of a config file, keeping the rest
+// of the file including the comments of a YAML config. An mcp.json file keeps
+// its format, so servers it cannot describe are rejected. The file is
+// replaced atomically, so that it is never left partially written.
+func SaveMCPServers(path string, servers map[string]MCPServerConfig) error {
+	var doc yaml.Node
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	format := DetectConfigFormat(path, data)
+	if format != ConfigFormatMCPBlade {
+		data, err := ExportMCPJSON(data, servers, format)
+		if err != nil {
+			return err
+		}
+
+		return WriteFileAtomic(path, data)
+	}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("config is not a mapping")
+	}
+
+	var value yaml.Node
+	if err := value.Encode(servers); err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value == "mcpServers" {
+			root.Content[i+1] = &value
+			replaced = true
+			break
+		}
+	}
+
+	if !replaced {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "mcpServers"}
+		root.Content = append(root.Content, key, &value)
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(path, buf.Bytes())
+}
+
+// WriteFileAtomic writes a file through a temporary file renamed over it, so
+// that an interrupted write never leaves it partially written. The file gets
+// the given mode, or else keeps the mode of the existing file, and the
+// temporary file has it before any data is written, so that the data is never
+// readable with a wider mode.
+func WriteFileAtomic(path string, data []byte, mode ...os.FileMode) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if len(mode) > 0 {
+		perm = mode[0]
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
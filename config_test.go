@@ -0,0 +1,96 @@
+package mcpblade
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSaveMCPServers(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	input := `# MCPBlade config
+cacheRefreshTTL: 10m
+mcpServers:
+  time:
+    transport: stdio
+    command: uvx
+vector:
+  collection: tools # tool documents
+`
+
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	servers := map[string]MCPServerConfig{
+		"remote": {
+			Transport: TransportTypeStreamableHTTP,
+			URL:       "https://example.com/mcp",
+			TTL:       Duration(time.Minute),
+		},
+	}
+
+	if err := SaveMCPServers(path, servers); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	output := string(data)
+	assert.Contains(output, "# MCPBlade config")
+	assert.Contains(output, "collection: tools # tool documents")
+	assert.NotContains(output, "uvx")
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(servers, cfg.MCPServers)
+	assert.Equal(10*time.Minute, cfg.CacheRefreshTTL)
+	assert.Equal("tools", cfg.Vector.Collection)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	// A missing config is created
+	path = filepath.Join(t.TempDir(), "new.yaml")
+
+	if err := SaveMCPServers(path, servers); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	cfg = Config{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(servers, cfg.MCPServers)
+}
@@ -9,11 +9,22 @@ import (
 )
 
 type EndpointSet struct {
-	RegisterMCPServer   endpoint.Endpoint
-	UnregisterMCPServer endpoint.Endpoint
-	ListTools           endpoint.Endpoint
-	SearchTools         endpoint.Endpoint
-	Forward             endpoint.Endpoint
+	RegisterMCPServer     endpoint.Endpoint
+	UpdateMCPServer       endpoint.Endpoint
+	UnregisterMCPServer   endpoint.Endpoint
+	Heartbeat             endpoint.Endpoint
+	AuthorizeMCPServer    endpoint.Endpoint
+	CompleteAuthorization endpoint.Endpoint
+	ListTools             endpoint.Endpoint
+	SearchTools           endpoint.Endpoint
+	Forward               endpoint.Endpoint
+
+	ListResources         endpoint.Endpoint
+	ListResourceTemplates endpoint.Endpoint
+	ReadResource          endpoint.Endpoint
+
+	ListPrompts endpoint.Endpoint
+	GetPrompt   endpoint.Endpoint
 }
 
 type RegisterMCPServerRequest struct {
@@ -29,27 +40,92 @@ func RegisterMCPServerEndpoint(svc Service) endpoint.Endpoint {
 			return nil, errors.New("invalid request type")
 		}
 
-		if req.Persistent {
-			return nil, errors.New("persistent registration is not supported")
+		if err := req.Config.Validate(); err != nil {
+			return nil, err
 		}
 
-		err := svc.RegisterMCPServer(ctx, req.ServerID, req.Config)
+		err := svc.RegisterMCPServer(ctx, req.ServerID, req.Config, req.Persistent)
 		return nil, err
 	}
 }
 
+type UpdateMCPServerRequest struct {
+	ServerID string          `json:"server_id"`
+	Config   MCPServerConfig `json:"config"`
+}
+
+func UpdateMCPServerEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(UpdateMCPServerRequest)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		if err := req.Config.Validate(); err != nil {
+			return nil, err
+		}
+
+		err := svc.UpdateMCPServer(ctx, req.ServerID, req.Config)
+		return nil, err
+	}
+}
+
+type UnregisterMCPServerRequest struct {
+	ServerID   string `json:"server_id"`
+	Persistent bool   `json:"persistent,omitempty"`
+}
+
 func UnregisterMCPServerEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(UnregisterMCPServerRequest)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		err := svc.UnregisterMCPServer(ctx, req.ServerID, req.Persistent)
+		return nil, err
+	}
+}
+
+func HeartbeatEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		serverID, ok := request.(string)
 		if !ok {
 			return nil, errors.New("invalid request type")
 		}
 
-		err := svc.UnregisterMCPServer(ctx, serverID)
+		err := svc.Heartbeat(ctx, serverID)
 		return nil, err
 	}
 }
 
+func AuthorizeMCPServerEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		serverID, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		return svc.AuthorizeMCPServer(ctx, serverID)
+	}
+}
+
+type CompleteAuthorizationRequest struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+func CompleteAuthorizationEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(CompleteAuthorizationRequest)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		return svc.CompleteAuthorization(ctx, req.State, req.Code)
+	}
+}
+
 func ListToolsEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		return svc.ListTools(ctx)
@@ -89,3 +165,47 @@ func ForwardEndpoint(svc Service) endpoint.Endpoint {
 		return resp, nil
 	}
 }
+
+func ListResourcesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return svc.ListResources(ctx)
+	}
+}
+
+func ListResourceTemplatesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return svc.ListResourceTemplates(ctx)
+	}
+}
+
+type ReadResourceRequest = mcp.ReadResourceRequest
+
+func ReadResourceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(ReadResourceRequest)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		return svc.ReadResource(ctx, req)
+	}
+}
+
+func ListPromptsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		return svc.ListPrompts(ctx)
+	}
+}
+
+type GetPromptRequest = mcp.GetPromptRequest
+
+func GetPromptEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(GetPromptRequest)
+		if !ok {
+			return nil, errors.New("invalid request type")
+		}
+
+		return svc.GetPrompt(ctx, req)
+	}
+}
@@ -0,0 +1,88 @@
+package mcpblade
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ClientConfig returns the TLS config of the connections to a remote server.
+// The CA is trusted in addition to the system roots.
+func (cfg TLSConfig) ClientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.Insecure,
+	}
+
+	if cfg.CA != "" {
+		pem, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + cfg.CA)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.Cert != "" || cfg.Key != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newHTTPClient returns the HTTP client of a remote server. The timeout
+// bounds dialing and the TLS handshake only: a tool call may take longer than
+// that before the response headers arrive, so requests are bounded by their
+// contexts instead, see dial for the initialization.
+func newHTTPClient(config MCPServerConfig) (*http.Client, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		t.TLSClientConfig = tlsConfig
+	}
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if timeout := config.Timeout.Duration(); timeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}
+
+		t.DialContext = dialer.DialContext
+		t.TLSHandshakeTimeout = timeout
+	}
+
+	return &http.Client{Transport: t}, nil
+}
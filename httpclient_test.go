@@ -0,0 +1,195 @@
+package mcpblade
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteServerWithHeadersAndTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("MCPBLADE_BACKEND_TOKEN", "backend-token")
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	streamable := server.NewStreamableHTTPServer(backend)
+
+	// The backend requires a bearer token and a client certificate
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer backend-token" || len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		streamable.ServeHTTP(w, r)
+	}))
+
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	// The certificate of the test server serves as CA and client certificate
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+
+	serverCert := ts.TLS.Certificates[0]
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	for path, data := range map[string][]byte{ca: certPEM, cert: certPEM, key: keyPEM} {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	ctx := context.Background()
+
+	config := MCPServerConfig{
+		Transport: TransportTypeStreamableHTTP,
+		URL:       ts.URL + "/mcp",
+		Headers: map[string]string{
+			"Authorization": "Bearer ${MCPBLADE_BACKEND_TOKEN}",
+		},
+		TLS: &TLSConfig{
+			CA:   ca,
+			Cert: cert,
+			Key:  key,
+		},
+		Timeout: Duration(5 * time.Second),
+	}
+
+	assert.NoError(config.Validate())
+
+	// Variables are expanded in the configs of the file only
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"remote": config,
+		},
+	}
+
+	svc, err := NewService(ctx, cfg, nil)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	tools, err := svc.ListTools(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(tools, 1) {
+		assert.Equal("echo", tools[0].Name)
+	}
+
+	// Without the token, the backend refuses the connection
+	config.Headers = nil
+
+	err = svc.RegisterMCPServer(ctx, "unauthorized", config, true)
+	assert.Error(err)
+
+	// Without the client certificate, the backend refuses the connection
+	config.Headers = map[string]string{"Authorization": "Bearer backend-token"}
+	config.TLS = &TLSConfig{CA: ca}
+
+	err = svc.RegisterMCPServer(ctx, "anonymous", config, true)
+	assert.Error(err)
+
+	// Without the CA, the certificate of the backend is not trusted
+	config.TLS = &TLSConfig{Cert: cert, Key: key}
+
+	err = svc.RegisterMCPServer(ctx, "untrusted", config, true)
+	assert.Error(err)
+
+	config.TLS.Insecure = true
+
+	err = svc.RegisterMCPServer(ctx, "insecure", config, true)
+	assert.NoError(err)
+}
+
+func TestRemoteServerTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(mcp.NewTool("slow"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		time.Sleep(300 * time.Millisecond)
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	ts := httptest.NewServer(server.NewStreamableHTTPServer(backend))
+	defer ts.Close()
+
+	// A server that never answers, until the client gives up
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+
+	ctx := context.Background()
+
+	svc, err := NewService(ctx, Config{}, nil)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	config := MCPServerConfig{
+		Transport: TransportTypeStreamableHTTP,
+		URL:       ts.URL + "/mcp",
+		Timeout:   Duration(100 * time.Millisecond),
+	}
+
+	if err := svc.RegisterMCPServer(ctx, "remote", config); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// The timeout does not cut off a tool call taking longer
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "slow",
+		},
+	}
+
+	result, err := svc.Forward(context.WithValue(ctx, ServerID, "remote"), req)
+	if assert.NoError(err) && assert.Len(result.Content, 1) {
+		assert.Equal("done", result.Content[0].(mcp.TextContent).Text)
+	}
+
+	// but it bounds the initialization
+	config.URL = hanging.URL + "/mcp"
+
+	start := time.Now()
+
+	err = svc.RegisterMCPServer(ctx, "hanging", config)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Less(time.Since(start), 2*time.Second)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func LoggingMiddleware(log *zap.Logger) ServiceMiddleware {
-	log = log.With(
+	log = log.WithOptions(RedactLogs()).With(
 		zap.String("service", "mcpblade"),
 	)
 
@@ -64,6 +64,79 @@ func (mw *loggingMiddleware) RegisterMCPServer(ctx context.Context, id string, c
 	return nil
 }
 
+func (mw *loggingMiddleware) UpdateMCPServer(ctx context.Context, id string, config MCPServerConfig) error {
+	log := mw.log.With(
+		zap.String("action", "update_mcp_server"),
+		zap.String("server_id", id),
+	)
+
+	err := mw.next.UpdateMCPServer(ctx, id, config)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	log.Info("mcp server updated")
+	return nil
+}
+
+func (mw *loggingMiddleware) ReloadMCPServers(ctx context.Context, servers map[string]MCPServerConfig) (ServerDiff, error) {
+	log := mw.log.With(
+		zap.String("action", "reload_mcp_servers"),
+	)
+
+	diff, err := mw.next.ReloadMCPServers(ctx, servers)
+	if err != nil {
+		log.Error(err.Error())
+		return diff, err
+	}
+
+	log.Info("mcp servers reloaded",
+		zap.Strings("added", diff.Added),
+		zap.Strings("removed", diff.Removed),
+		zap.Strings("restarted", diff.Changed),
+		zap.Strings("updated", diff.Updated),
+		zap.Strings("unchanged", diff.Unchanged),
+		zap.Strings("failed", diff.Failed),
+	)
+
+	return diff, nil
+}
+
+func (mw *loggingMiddleware) AuthorizeMCPServer(ctx context.Context, id string) (string, error) {
+	log := mw.log.With(
+		zap.String("action", "authorize_mcp_server"),
+		zap.String("server_id", id),
+	)
+
+	authURL, err := mw.next.AuthorizeMCPServer(ctx, id)
+	if err != nil {
+		log.Error(err.Error())
+		return "", err
+	}
+
+	log.Info("authorization started")
+	return authURL, nil
+}
+
+func (mw *loggingMiddleware) CompleteAuthorization(ctx context.Context, state string, code string) (string, error) {
+	log := mw.log.With(
+		zap.String("action", "complete_authorization"),
+	)
+
+	id, err := mw.next.CompleteAuthorization(ctx, state, code)
+	if err != nil {
+		log.Error(err.Error())
+		return id, err
+	}
+
+	log.Info("authorization completed",
+		zap.String("server_id", id),
+	)
+
+	return id, nil
+}
+
 func (mw *loggingMiddleware) UnregisterMCPServer(ctx context.Context, id string, persistent ...bool) error {
 	isPersistent := false
 	if len(persistent) > 0 {
@@ -86,6 +159,22 @@ func (mw *loggingMiddleware) UnregisterMCPServer(ctx context.Context, id string,
 	return nil
 }
 
+func (mw *loggingMiddleware) Heartbeat(ctx context.Context, id string) error {
+	log := mw.log.With(
+		zap.String("action", "heartbeat"),
+		zap.String("server_id", id),
+	)
+
+	err := mw.next.Heartbeat(ctx, id)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+
+	log.Debug("heartbeat received")
+	return nil
+}
+
 func (mw *loggingMiddleware) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 	log := mw.log.With(
 		zap.String("action", "list_tools"),
@@ -157,3 +246,136 @@ func (mw *loggingMiddleware) Forward(ctx context.Context, req mcp.CallToolReques
 	log.Info("request forwarded")
 	return result, nil
 }
+
+func (mw *loggingMiddleware) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	log := mw.log.With(
+		zap.String("action", "list_resources"),
+	)
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if ok {
+		log = log.With(
+			zap.String("server_id", serverID),
+		)
+	}
+
+	resources, err := mw.next.ListResources(ctx)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	log.Info("resources listed", zap.Int("count", len(resources)))
+	return resources, nil
+}
+
+func (mw *loggingMiddleware) ListResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplate, error) {
+	log := mw.log.With(
+		zap.String("action", "list_resource_templates"),
+	)
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if ok {
+		log = log.With(
+			zap.String("server_id", serverID),
+		)
+	}
+
+	templates, err := mw.next.ListResourceTemplates(ctx)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	log.Info("resource templates listed", zap.Int("count", len(templates)))
+	return templates, nil
+}
+
+func (mw *loggingMiddleware) ReadResource(ctx context.Context, req mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	log := mw.log.With(
+		zap.String("action", "read_resource"),
+		zap.String("uri", req.Params.URI),
+	)
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if ok {
+		log = log.With(
+			zap.String("server_id", serverID),
+		)
+	}
+
+	result, err := mw.next.ReadResource(ctx, req)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	log.Info("resource read", zap.Int("contents", len(result.Contents)))
+	return result, nil
+}
+
+func (mw *loggingMiddleware) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	log := mw.log.With(
+		zap.String("action", "list_prompts"),
+	)
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if ok {
+		log = log.With(
+			zap.String("server_id", serverID),
+		)
+	}
+
+	prompts, err := mw.next.ListPrompts(ctx)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	log.Info("prompts listed", zap.Int("count", len(prompts)))
+	return prompts, nil
+}
+
+func (mw *loggingMiddleware) GetPrompt(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	log := mw.log.With(
+		zap.String("action", "get_prompt"),
+		zap.String("prompt", req.Params.Name),
+	)
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if ok {
+		log = log.With(
+			zap.String("server_id", serverID),
+		)
+	}
+
+	result, err := mw.next.GetPrompt(ctx, req)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	log.Info("prompt retrieved")
+	return result, nil
+}
+
+func (mw *loggingMiddleware) OnNotification(handler NotificationHandler) {
+	log := mw.log.With(
+		zap.String("action", "notification"),
+	)
+
+	mw.next.OnNotification(func(serverID string, notification mcp.JSONRPCNotification) {
+		log := log.With(
+			zap.String("method", notification.Method),
+		)
+
+		if serverID != "" {
+			log = log.With(
+				zap.String("server_id", serverID),
+			)
+		}
+
+		log.Info("notification relayed")
+		handler(serverID, notification)
+	})
+}
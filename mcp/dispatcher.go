@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Dispatcher routes JSON-RPC messages to the MCP endpoints, and is shared by
+// the transports serving MCP.
+type Dispatcher struct {
+	endpoints map[mcp.MCPMethod]MCPEndpoint
+	inflight  *InflightRequests
+}
+
+// NewDispatcher returns a dispatcher for the given endpoints. With inflight
+// set, requests are tracked by ID, so that notifications/cancelled cancels
+// them and no response is returned for cancelled requests.
+func NewDispatcher(endpoints map[mcp.MCPMethod]MCPEndpoint, inflight ...*InflightRequests) *Dispatcher {
+	d := &Dispatcher{
+		endpoints: endpoints,
+	}
+
+	if len(inflight) > 0 {
+		d.inflight = inflight[0]
+	}
+
+	return d
+}
+
+// jsonrpcMessage is any JSON-RPC message sent by a client, which may also be
+// a response to a request of the server.
+type jsonrpcMessage struct {
+	JSONRPCRequest
+
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Dispatch handles a JSON-RPC message or batch. It returns the response, a
+// []mcp.JSONRPCMessage for a batch, or nil if there is nothing to respond,
+// i.e. only notifications and responses were received.
+func (d *Dispatcher) Dispatch(ctx context.Context, data []byte) any {
+	data = bytes.TrimSpace(data)
+
+	// A parse error is only reported for the whole payload, as the messages
+	// of a valid batch are valid JSON
+	if !json.Valid(data) {
+		return mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, "parse error", nil)
+	}
+
+	if data[0] != '[' {
+		return d.handle(ctx, data)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, err.Error(), nil)
+	}
+
+	if len(batch) == 0 {
+		return mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "empty batch", nil)
+	}
+
+	// Messages of a batch are processed concurrently, and the responses are
+	// matched to the requests by ID
+	resps := make([]mcp.JSONRPCMessage, len(batch))
+
+	var wg sync.WaitGroup
+	for i, msg := range batch {
+		wg.Add(1)
+
+		go func(i int, msg json.RawMessage) {
+			defer wg.Done()
+
+			resps[i] = d.handle(ctx, msg)
+		}(i, msg)
+	}
+
+	wg.Wait()
+
+	results := make([]mcp.JSONRPCMessage, 0, len(resps))
+	for _, resp := range resps {
+		if resp != nil {
+			results = append(results, resp)
+		}
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	return results
+}
+
+// handle handles a single JSON-RPC message, and returns nil for notifications
+// and responses. A message that is not a request object is an invalid request.
+func (d *Dispatcher) handle(ctx context.Context, data []byte) mcp.JSONRPCMessage {
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, err.Error(), nil)
+	}
+
+	req := msg.JSONRPCRequest
+
+	if req.JSONRPC != mcp.JSONRPC_VERSION {
+		return mcp.NewJSONRPCError(req.ID, mcp.INVALID_REQUEST, "invalid jsonrpc version", nil)
+	}
+
+	if req.Method == "" {
+		// Responses to requests of the server are accepted
+		if !req.ID.IsNil() && (msg.Result != nil || msg.Error != nil) {
+			return nil
+		}
+
+		return mcp.NewJSONRPCError(req.ID, mcp.INVALID_REQUEST, "missing method", nil)
+	}
+
+	if req.ID.IsNil() {
+		d.notify(req)
+		return nil
+	}
+
+	endpoint, ok := d.endpoints[req.Method]
+	if !ok {
+		return mcp.NewJSONRPCError(req.ID, mcp.METHOD_NOT_FOUND, "method not found", nil)
+	}
+
+	if d.inflight == nil {
+		return endpoint(ctx, req)
+	}
+
+	ctx, done := d.inflight.Track(ctx, req.ID.String())
+	defer done()
+
+	resp := endpoint(ctx, req)
+
+	// No response is sent for cancelled requests
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return resp
+}
+
+// Cancel handles a notifications/cancelled message, and reports false for
+// any other message. Transports bounding the requests processed concurrently
+// call it as soon as a message is read, since the request it cancels may be
+// waiting for a free worker.
+func (d *Dispatcher) Cancel(data []byte) bool {
+	if d.inflight == nil {
+		return false
+	}
+
+	var req JSONRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return false
+	}
+
+	id, ok := CancelledRequestID(req)
+	if !ok {
+		return false
+	}
+
+	d.inflight.Cancel(id.String())
+	return true
+}
+
+// notify handles a notification. Notifications other than
+// notifications/cancelled need no handling and are ignored.
+func (d *Dispatcher) notify(req JSONRPCRequest) {
+	if d.inflight == nil {
+		return
+	}
+
+	if id, ok := CancelledRequestID(req); ok {
+		d.inflight.Cancel(id.String())
+	}
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func pingEndpoint(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+	return mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      req.ID,
+		Result:  struct{}{},
+	}
+}
+
+func marshal(v any) string {
+	bs, _ := json.Marshal(v)
+	return string(bs)
+}
+
+func TestDispatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	dispatcher := NewDispatcher(map[mcp.MCPMethod]MCPEndpoint{
+		mcp.MethodPing: pingEndpoint,
+	})
+
+	ctx := context.Background()
+
+	resp := dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	assert.JSONEq(`{"jsonrpc":"2.0","id":1,"result":{}}`, marshal(resp))
+
+	// Notifications and responses are not responded
+	resp = dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	assert.Nil(resp)
+
+	resp = dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":"s1","result":{}}`))
+	assert.Nil(resp)
+
+	resp = dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"unknown"}`))
+	assert.JSONEq(`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found"}}`, marshal(resp))
+
+	resp = dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":3,`))
+	assert.JSONEq(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`, marshal(resp))
+
+	resp = dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":4}`))
+	assert.JSONEq(`{"jsonrpc":"2.0","id":4,"error":{"code":-32600,"message":"missing method"}}`, marshal(resp))
+
+	resp = dispatcher.Dispatch(ctx, []byte(`"ping"`))
+	if err, ok := resp.(mcp.JSONRPCError); assert.True(ok) {
+		assert.Equal(mcp.INVALID_REQUEST, err.Error.Code)
+	}
+}
+
+func TestDispatcherBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	dispatcher := NewDispatcher(map[mcp.MCPMethod]MCPEndpoint{
+		mcp.MethodPing: pingEndpoint,
+	})
+
+	ctx := context.Background()
+
+	resp := dispatcher.Dispatch(ctx, []byte(`[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"unknown"},
+		{"jsonrpc":"2.0","id":3}
+	]`))
+
+	assert.JSONEq(`[
+		{"jsonrpc":"2.0","id":1,"result":{}},
+		{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found"}},
+		{"jsonrpc":"2.0","id":3,"error":{"code":-32600,"message":"missing method"}}
+	]`, marshal(resp))
+
+	// Malformed messages of a valid batch are invalid requests, and do not
+	// affect the other messages
+	resp = dispatcher.Dispatch(ctx, []byte(`[1,{"jsonrpc":"2.0","id":1,"method":"ping"},"ping"]`))
+	if resps, ok := resp.([]mcp.JSONRPCMessage); assert.True(ok) && assert.Len(resps, 3) {
+		for _, i := range []int{0, 2} {
+			if err, ok := resps[i].(mcp.JSONRPCError); assert.True(ok) {
+				assert.Equal(mcp.INVALID_REQUEST, err.Error.Code)
+			}
+		}
+
+		assert.JSONEq(`{"jsonrpc":"2.0","id":1,"result":{}}`, marshal(resps[1]))
+	}
+
+	// A batch of notifications is not responded
+	resp = dispatcher.Dispatch(ctx, []byte(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`))
+	assert.Nil(resp)
+
+	resp = dispatcher.Dispatch(ctx, []byte(`[]`))
+	if err, ok := resp.(mcp.JSONRPCError); assert.True(ok) {
+		assert.Equal(mcp.INVALID_REQUEST, err.Error.Code)
+	}
+
+	resp = dispatcher.Dispatch(ctx, []byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err, ok := resp.(mcp.JSONRPCError); assert.True(ok) {
+		assert.Equal(mcp.PARSE_ERROR, err.Error.Code)
+	}
+}
+
+func TestDispatcherCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	started := make(chan struct{})
+
+	dispatcher := NewDispatcher(map[mcp.MCPMethod]MCPEndpoint{
+		mcp.MethodToolsCall: func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+			close(started)
+			<-ctx.Done()
+
+			return mcp.NewJSONRPCError(req.ID, mcp.INTERNAL_ERROR, ctx.Err().Error(), nil)
+		},
+	}, NewInflightRequests())
+
+	ctx := context.Background()
+
+	resps := make(chan any, 1)
+	go func() {
+		resps <- dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call"}`))
+	}()
+
+	<-started
+
+	resp := dispatcher.Dispatch(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`))
+	assert.Nil(resp)
+
+	// No response is returned for the cancelled request
+	select {
+	case resp := <-resps:
+		assert.Nil(resp)
+
+	case <-time.After(time.Second):
+		assert.Fail("request not cancelled")
+	}
+}
+
+func TestDispatcherCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	inflight := NewInflightRequests()
+	dispatcher := NewDispatcher(map[mcp.MCPMethod]MCPEndpoint{}, inflight)
+
+	var req JSONRPCRequest
+	json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call"}`), &req)
+
+	ctx, done := inflight.Track(context.Background(), req.ID.String())
+	defer done()
+
+	assert.False(dispatcher.Cancel([]byte(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)))
+	assert.NoError(ctx.Err())
+
+	assert.True(dispatcher.Cancel([]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`)))
+	assert.Error(ctx.Err())
+}
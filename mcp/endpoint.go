@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"slices"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -44,11 +45,26 @@ const MCPSERVER_INSTRUCTIONS string = `MCPBlade aggregates and manages tools fro
 Available operations:
 - tools/list: Get all available tools
 - tools/call: Execute tools (automatically routed)
-- search_tools: Find tools using semantic search
+- search_tools: Built-in tool to find tools using semantic search
+- resources/list, resources/templates/list, resources/read: Access resources of connected servers
+- prompts/list, prompts/get: Use prompts of connected servers
 
 All tools are enhanced with server information and deduplicated for easy discovery.`
 
-func InitializeEndpoint(svc mcpblade.Service) MCPEndpoint {
+const MCPSERVER_LAZY_INSTRUCTIONS string = `MCPBlade aggregates tools from multiple MCP servers. To keep the context small, the tools are not listed directly:
+
+1. search_tools: Find tools for a task using a natural language query
+2. describe_tool: Get the full definition and input schema of a tool by name
+3. invoke_tool: Call a tool by name with arguments matching its input schema
+
+Search first, then invoke the best matching tool.`
+
+func InitializeEndpoint(svc mcpblade.Service, lazy ...bool) MCPEndpoint {
+	instructions := MCPSERVER_INSTRUCTIONS
+	if len(lazy) > 0 && lazy[0] {
+		instructions = MCPSERVER_LAZY_INSTRUCTIONS
+	}
+
 	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
 		var params mcp.InitializeParams
 		if err := json.Unmarshal(req.Params, &params); err != nil {
@@ -67,13 +83,26 @@ func InitializeEndpoint(svc mcpblade.Service) MCPEndpoint {
 			Capabilities: mcp.ServerCapabilities{
 				Tools: &struct {
 					ListChanged bool `json:"listChanged,omitempty"`
-				}{},
+				}{
+					ListChanged: true,
+				},
+				Resources: &struct {
+					Subscribe   bool `json:"subscribe,omitempty"`
+					ListChanged bool `json:"listChanged,omitempty"`
+				}{
+					ListChanged: true,
+				},
+				Prompts: &struct {
+					ListChanged bool `json:"listChanged,omitempty"`
+				}{
+					ListChanged: true,
+				},
 			},
 			ServerInfo: mcp.Implementation{
 				Name:    "mcpblade",
 				Version: "1.0.0",
 			},
-			Instructions: MCPSERVER_INSTRUCTIONS,
+			Instructions: instructions,
 		}
 
 		return mcp.JSONRPCResponse{
@@ -94,13 +123,56 @@ func PingEndpoint(svc mcpblade.Service) MCPEndpoint {
 	}
 }
 
-func ListToolsEndpoint(svc mcpblade.Service) MCPEndpoint {
+// ListToolsEndpoint lists the backend tools together with search_tools. In
+// lazy mode only the meta-tools are listed, and the model discovers and calls
+// the backend tools through them.
+func ListToolsEndpoint(svc mcpblade.Service, lazy ...bool) MCPEndpoint {
+	isLazy := false
+	if len(lazy) > 0 {
+		isLazy = lazy[0]
+	}
+
 	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
-		tools, err := svc.ListTools(ctx)
-		if err != nil {
-			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+		if isLazy && isAggregated(ctx) {
+			tools := make([]mcp.Tool, len(MetaTools))
+			copy(tools, MetaTools)
+
+			return mcp.JSONRPCResponse{
+				JSONRPC: mcp.JSONRPC_VERSION,
+				ID:      req.ID,
+				Result: &mcp.ListToolsResult{
+					Tools: tools,
+				},
+			}
+		}
+
+		if !isAggregated(ctx) {
+			tools, err := svc.ListTools(ctx)
+			if err != nil {
+				return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+			}
+
+			return mcp.JSONRPCResponse{
+				JSONRPC: mcp.JSONRPC_VERSION,
+				ID:      req.ID,
+				Result: &mcp.ListToolsResult{
+					Tools: tools,
+				},
+			}
 		}
 
+		// search_tools remains available when the backend tools cannot be
+		// listed, e.g. before any server is connected
+		tools, _ := svc.ListTools(ctx)
+
+		// A backend tool named search_tools is shadowed by the built-in one,
+		// which handles its calls
+		tools = slices.DeleteFunc(slices.Clone(tools), func(tool mcp.Tool) bool {
+			return tool.Name == SearchToolsToolName
+		})
+
+		tools = append(tools, SearchToolsTool)
+
 		result := &mcp.ListToolsResult{
 			Tools: tools,
 		}
@@ -113,7 +185,14 @@ func ListToolsEndpoint(svc mcpblade.Service) MCPEndpoint {
 	}
 }
 
-func CallToolEndpoint(svc mcpblade.Service) MCPEndpoint {
+// CallToolEndpoint calls a backend tool, or a meta-tool listed by
+// ListToolsEndpoint in the same mode.
+func CallToolEndpoint(svc mcpblade.Service, lazy ...bool) MCPEndpoint {
+	isLazy := false
+	if len(lazy) > 0 {
+		isLazy = lazy[0]
+	}
+
 	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
 		var params mcp.CallToolParams
 		if err := json.Unmarshal(req.Params, &params); err != nil {
@@ -127,6 +206,14 @@ func CallToolEndpoint(svc mcpblade.Service) MCPEndpoint {
 			Params: params,
 		}
 
+		if result, ok := callMetaTool(ctx, svc, callToolReq, isLazy); ok {
+			return mcp.JSONRPCResponse{
+				JSONRPC: mcp.JSONRPC_VERSION,
+				ID:      req.ID,
+				Result:  result,
+			}
+		}
+
 		result, err := svc.Forward(ctx, callToolReq)
 		if err != nil {
 			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
@@ -139,3 +226,125 @@ func CallToolEndpoint(svc mcpblade.Service) MCPEndpoint {
 		}
 	}
 }
+
+func ListResourcesEndpoint(svc mcpblade.Service) MCPEndpoint {
+	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+		resources, err := svc.ListResources(ctx)
+		if err != nil {
+			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+		}
+
+		result := &mcp.ListResourcesResult{
+			Resources: resources,
+		}
+
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+}
+
+func ListResourceTemplatesEndpoint(svc mcpblade.Service) MCPEndpoint {
+	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+		templates, err := svc.ListResourceTemplates(ctx)
+		if err != nil {
+			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+		}
+
+		result := &mcp.ListResourceTemplatesResult{
+			ResourceTemplates: templates,
+		}
+
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+}
+
+func ReadResourceEndpoint(svc mcpblade.Service) MCPEndpoint {
+	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+		var params mcp.ReadResourceParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, mcp.INVALID_PARAMS, err.Error())
+		}
+
+		readResourceReq := mcp.ReadResourceRequest{
+			Request: mcp.Request{
+				Method: string(req.Method),
+			},
+			Params: params,
+		}
+
+		result, err := svc.ReadResource(ctx, readResourceReq)
+		if err != nil {
+			switch {
+			case errors.Is(err, mcpblade.ErrInvalidResourceURI):
+				return errorResponse(req.ID, mcp.INVALID_PARAMS, err.Error())
+			case errors.Is(err, mcpblade.ErrResourceNotFound):
+				return errorResponse(req.ID, mcp.RESOURCE_NOT_FOUND, err.Error())
+			default:
+				return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+			}
+		}
+
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+}
+
+func ListPromptsEndpoint(svc mcpblade.Service) MCPEndpoint {
+	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+		prompts, err := svc.ListPrompts(ctx)
+		if err != nil {
+			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+		}
+
+		result := &mcp.ListPromptsResult{
+			Prompts: prompts,
+		}
+
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+}
+
+func GetPromptEndpoint(svc mcpblade.Service) MCPEndpoint {
+	return func(ctx context.Context, req JSONRPCRequest) mcp.JSONRPCMessage {
+		var params mcp.GetPromptParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, mcp.INVALID_PARAMS, err.Error())
+		}
+
+		getPromptReq := mcp.GetPromptRequest{
+			Request: mcp.Request{
+				Method: string(req.Method),
+			},
+			Params: params,
+		}
+
+		result, err := svc.GetPrompt(ctx, getPromptReq)
+		if err != nil {
+			if errors.Is(err, mcpblade.ErrPromptNotFound) {
+				return errorResponse(req.ID, mcp.INVALID_PARAMS, err.Error())
+			}
+
+			return errorResponse(req.ID, mcp.INTERNAL_ERROR, err.Error())
+		}
+
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/mcpblade"
 )
 
 func TestUnmarshalInitializeRequest(t *testing.T) {
@@ -89,3 +92,249 @@ func TestUnmarshalCallToolRequest(t *testing.T) {
 		return
 	}
 }
+
+// stubService implements the service methods used by the MCP endpoints;
+// calling any other method panics.
+type stubService struct {
+	mcpblade.Service
+	tools []mcp.Tool
+	err   error
+}
+
+func (svc *stubService) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	if svc.err != nil {
+		return nil, svc.err
+	}
+
+	return svc.tools, nil
+}
+
+func (svc *stubService) SearchTools(ctx context.Context, query string, k ...int) ([]mcp.Tool, error) {
+	n := len(svc.tools)
+	if len(k) > 0 && k[0] > 0 && k[0] < n {
+		n = k[0]
+	}
+
+	return svc.tools[:n], nil
+}
+
+func (svc *stubService) Forward(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText("forwarded " + req.Params.Name), nil
+}
+
+func newStubService() *stubService {
+	return &stubService{
+		tools: []mcp.Tool{
+			mcp.NewTool("get_current_time"),
+			mcp.NewTool("convert_time"),
+		},
+	}
+}
+
+func TestListToolsEndpointSearchTools(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint := ListToolsEndpoint(newStubService())
+
+	req := JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Method:  mcp.MethodToolsList,
+	}
+
+	resp, ok := endpoint(context.Background(), req).(mcp.JSONRPCResponse)
+	if !ok {
+		assert.Fail("invalid response type")
+		return
+	}
+
+	result := resp.Result.(*mcp.ListToolsResult)
+	assert.Len(result.Tools, 3)
+	assert.Equal(SearchToolsToolName, result.Tools[2].Name)
+
+	// Dedicated servers only expose their own tools
+	ctx := context.WithValue(context.Background(), mcpblade.ServerID, "test")
+
+	resp = endpoint(ctx, req).(mcp.JSONRPCResponse)
+	result = resp.Result.(*mcp.ListToolsResult)
+	assert.Len(result.Tools, 2)
+}
+
+func TestCallToolEndpointSearchTools(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint := CallToolEndpoint(newStubService())
+
+	req := JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(2)),
+		Method:  mcp.MethodToolsCall,
+		Params:  json.RawMessage(`{"name":"search_tools","arguments":{"query":"time","k":1}}`),
+	}
+
+	resp, ok := endpoint(context.Background(), req).(mcp.JSONRPCResponse)
+	if !ok {
+		assert.Fail("invalid response type")
+		return
+	}
+
+	bs, err := json.Marshal(resp.Result)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var result struct {
+		IsError           bool `json:"isError"`
+		StructuredContent struct {
+			Tools []mcp.Tool `json:"tools"`
+		} `json:"structuredContent"`
+	}
+
+	if err := json.Unmarshal(bs, &result); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.False(result.IsError)
+	assert.Len(result.StructuredContent.Tools, 1)
+	assert.Equal("get_current_time", result.StructuredContent.Tools[0].Name)
+
+	// A missing query is reported as a tool error
+	req.Params = json.RawMessage(`{"name":"search_tools","arguments":{}}`)
+
+	resp = endpoint(context.Background(), req).(mcp.JSONRPCResponse)
+	assert.True(resp.Result.(*CallToolResult).IsError)
+}
+
+func TestLazyToolExposure(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := newStubService()
+
+	req := JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Method:  mcp.MethodToolsList,
+	}
+
+	resp := ListToolsEndpoint(svc, true)(context.Background(), req).(mcp.JSONRPCResponse)
+	result := resp.Result.(*mcp.ListToolsResult)
+
+	assert.Len(result.Tools, 3)
+	assert.Equal(SearchToolsToolName, result.Tools[0].Name)
+	assert.Equal(DescribeToolToolName, result.Tools[1].Name)
+	assert.Equal(InvokeToolToolName, result.Tools[2].Name)
+
+	call := CallToolEndpoint(svc, true)
+
+	req = JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(2)),
+		Method:  mcp.MethodToolsCall,
+		Params:  json.RawMessage(`{"name":"describe_tool","arguments":{"name":"convert_time"}}`),
+	}
+
+	resp = call(context.Background(), req).(mcp.JSONRPCResponse)
+	described := resp.Result.(*CallToolResult)
+	assert.False(described.IsError)
+	assert.Equal("convert_time", described.StructuredContent.(mcp.Tool).Name)
+
+	req.Params = json.RawMessage(`{"name":"describe_tool","arguments":{"name":"unknown"}}`)
+
+	resp = call(context.Background(), req).(mcp.JSONRPCResponse)
+	assert.True(resp.Result.(*CallToolResult).IsError)
+
+	req.Params = json.RawMessage(`{"name":"invoke_tool","arguments":{"name":"convert_time","arguments":{"time":"16:30"}}}`)
+
+	resp = call(context.Background(), req).(mcp.JSONRPCResponse)
+	invoked := resp.Result.(*CallToolResult)
+	assert.False(invoked.IsError)
+	assert.Equal("forwarded convert_time", invoked.Content[0].(mcp.TextContent).Text)
+}
+
+func TestMetaToolsWithoutLazy(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := newStubService()
+	svc.tools = append(svc.tools,
+		mcp.NewTool(SearchToolsToolName),
+		mcp.NewTool(DescribeToolToolName),
+	)
+
+	req := JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Method:  mcp.MethodToolsList,
+	}
+
+	// A backend search_tools is shadowed by the built-in one, and listed once
+	resp := ListToolsEndpoint(svc)(context.Background(), req).(mcp.JSONRPCResponse)
+	result := resp.Result.(*mcp.ListToolsResult)
+
+	names := make([]string, 0)
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+
+	assert.Equal([]string{"get_current_time", "convert_time", DescribeToolToolName, SearchToolsToolName}, names)
+
+	// Meta-tools not advertised are forwarded to the backends
+	req = JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(2)),
+		Method:  mcp.MethodToolsCall,
+		Params:  json.RawMessage(`{"name":"describe_tool","arguments":{"name":"convert_time"}}`),
+	}
+
+	resp = CallToolEndpoint(svc)(context.Background(), req).(mcp.JSONRPCResponse)
+	forwarded := resp.Result.(*mcp.CallToolResult)
+	assert.Equal("forwarded describe_tool", forwarded.Content[0].(mcp.TextContent).Text)
+
+	// The instructions only mention the meta-tools handled in each mode
+	req = JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(3)),
+		Method:  mcp.MethodInitialize,
+		Params:  json.RawMessage(`{}`),
+	}
+
+	resp = InitializeEndpoint(svc)(context.Background(), req).(mcp.JSONRPCResponse)
+	instructions := resp.Result.(*mcp.InitializeResult).Instructions
+	assert.Contains(instructions, SearchToolsToolName)
+	assert.NotContains(instructions, DescribeToolToolName)
+	assert.NotContains(instructions, InvokeToolToolName)
+
+	resp = InitializeEndpoint(svc, true)(context.Background(), req).(mcp.JSONRPCResponse)
+	instructions = resp.Result.(*mcp.InitializeResult).Instructions
+	assert.Contains(instructions, DescribeToolToolName)
+	assert.Contains(instructions, InvokeToolToolName)
+}
+
+func TestListToolsEndpointWithoutTools(t *testing.T) {
+	assert := assert.New(t)
+
+	svc := newStubService()
+	svc.err = mcpblade.ErrNoToolsFound
+
+	req := JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Method:  mcp.MethodToolsList,
+	}
+
+	// search_tools is listed even if the backend tools cannot be listed
+	resp, ok := ListToolsEndpoint(svc)(context.Background(), req).(mcp.JSONRPCResponse)
+	if assert.True(ok) {
+		result := resp.Result.(*mcp.ListToolsResult)
+		if assert.Len(result.Tools, 1) {
+			assert.Equal(SearchToolsToolName, result.Tools[0].Name)
+		}
+	}
+
+	// Dedicated servers report the error
+	ctx := context.WithValue(context.Background(), mcpblade.ServerID, "test")
+
+	_, ok = ListToolsEndpoint(svc)(ctx, req).(mcp.JSONRPCError)
+	assert.True(ok)
+}
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/flarexio/mcpblade"
+)
+
+// InflightRequests tracks the contexts of in-flight requests, so that they
+// can be cancelled by notifications/cancelled.
+type InflightRequests struct {
+	requests map[string]*inflightRequest
+	mu       sync.Mutex
+}
+
+type inflightRequest struct {
+	cancel context.CancelFunc
+}
+
+func NewInflightRequests() *InflightRequests {
+	return &InflightRequests{
+		requests: make(map[string]*inflightRequest),
+	}
+}
+
+// Track returns the context of a request, which is cancelled by Cancel with
+// the same key, and the function to be called once the request has completed.
+func (r *InflightRequests) Track(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	req := &inflightRequest{cancel: cancel}
+
+	r.mu.Lock()
+	r.requests[key] = req
+	r.mu.Unlock()
+
+	done := func() {
+		cancel()
+
+		r.mu.Lock()
+		if r.requests[key] == req {
+			delete(r.requests, key)
+		}
+		r.mu.Unlock()
+	}
+
+	return ctx, done
+}
+
+// Cancel cancels the in-flight request with the given key, and reports
+// whether it was found.
+func (r *InflightRequests) Cancel(key string) bool {
+	r.mu.Lock()
+	req, ok := r.requests[key]
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	req.cancel()
+	return true
+}
+
+// CancelledRequestID returns the ID of the request cancelled by a
+// notifications/cancelled message, and false for any other message.
+func CancelledRequestID(req JSONRPCRequest) (mcp.RequestId, bool) {
+	if req.Method != mcpblade.MethodNotificationCancelled {
+		return mcp.RequestId{}, false
+	}
+
+	var params struct {
+		RequestID mcp.RequestId `json:"requestId"`
+		Reason    string        `json:"reason,omitempty"`
+	}
+
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return mcp.RequestId{}, false
+	}
+
+	if params.RequestID.IsNil() {
+		return mcp.RequestId{}, false
+	}
+
+	return params.RequestID, true
+}
@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflightRequests(t *testing.T) {
+	assert := assert.New(t)
+
+	inflight := NewInflightRequests()
+
+	ctx, done := inflight.Track(context.Background(), "1")
+
+	assert.False(inflight.Cancel("2"))
+	assert.NoError(ctx.Err())
+
+	assert.True(inflight.Cancel("1"))
+	assert.ErrorIs(ctx.Err(), context.Canceled)
+
+	done()
+	assert.False(inflight.Cancel("1"))
+
+	// A completed request does not untrack a new one with the same key
+	_, done1 := inflight.Track(context.Background(), "1")
+	ctx2, done2 := inflight.Track(context.Background(), "1")
+	defer done2()
+
+	done1()
+
+	assert.True(inflight.Cancel("1"))
+	assert.ErrorIs(ctx2.Err(), context.Canceled)
+}
+
+func TestCancelledRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	var req JSONRPCRequest
+	err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"timeout"}}`), &req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	id, ok := CancelledRequestID(req)
+	if assert.True(ok) {
+		assert.Equal(mcp.NewRequestId(int64(7)).String(), id.String())
+	}
+
+	req.Method = mcp.MethodPing
+
+	_, ok = CancelledRequestID(req)
+	assert.False(ok)
+}
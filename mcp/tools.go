@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/flarexio/mcpblade"
+)
+
+// CallToolResult extends mcp.CallToolResult with structured content, which
+// is not supported by mcp-go yet.
+type CallToolResult struct {
+	mcp.CallToolResult
+	StructuredContent any `json:"structuredContent,omitempty"`
+}
+
+const SearchToolsToolName = "search_tools"
+
+// SearchToolsTool is the built-in meta-tool for semantic tool search.
+var SearchToolsTool = mcp.NewTool(SearchToolsToolName,
+	mcp.WithDescription("Search the tools of all connected MCP servers using a natural language query. Returns the matching tool definitions, including their input schemas."),
+	mcp.WithString("query",
+		mcp.Required(),
+		mcp.Description("Natural language description of the task or tool to find"),
+	),
+	mcp.WithNumber("k",
+		mcp.Description("Maximum number of tools to return"),
+		mcp.DefaultNumber(5),
+		mcp.Min(1),
+	),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+const (
+	DescribeToolToolName = "describe_tool"
+	InvokeToolToolName   = "invoke_tool"
+)
+
+// DescribeToolTool is the built-in meta-tool returning a tool definition.
+var DescribeToolTool = mcp.NewTool(DescribeToolToolName,
+	mcp.WithDescription("Describe a tool of the connected MCP servers by name. Returns the tool definition, including its input schema."),
+	mcp.WithString("name",
+		mcp.Required(),
+		mcp.Description("Name of the tool, as returned by search_tools"),
+	),
+	mcp.WithReadOnlyHintAnnotation(true),
+)
+
+// InvokeToolTool is the built-in meta-tool calling a tool by name.
+var InvokeToolTool = mcp.NewTool(InvokeToolToolName,
+	mcp.WithDescription("Invoke a tool of the connected MCP servers by name. Use search_tools or describe_tool first to learn its input schema."),
+	mcp.WithString("name",
+		mcp.Required(),
+		mcp.Description("Name of the tool, as returned by search_tools"),
+	),
+	mcp.WithObject("arguments",
+		mcp.Description("Arguments of the tool, matching its input schema"),
+	),
+)
+
+// MetaTools are the tools listed in lazy mode instead of the backend tools.
+var MetaTools = []mcp.Tool{
+	SearchToolsTool,
+	DescribeToolTool,
+	InvokeToolTool,
+}
+
+// isAggregated reports whether the request targets the aggregated servers
+// rather than a dedicated temporary server; meta-tools only apply to those.
+func isAggregated(ctx context.Context) bool {
+	_, ok := ctx.Value(mcpblade.ServerID).(string)
+	return !ok
+}
+
+func searchTools(ctx context.Context, svc mcpblade.Service, req mcp.CallToolRequest) *CallToolResult {
+	query, err := req.RequireString("query")
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	k := req.GetInt("k", 0)
+
+	tools, err := svc.SearchTools(ctx, query, k)
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	return structuredResult(map[string]any{
+		"tools": tools,
+	})
+}
+
+// structuredResult returns the content both as structured content and, for
+// clients without structured content support, as serialized JSON text.
+func structuredResult(content any) *CallToolResult {
+	bs, err := json.Marshal(content)
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	return &CallToolResult{
+		CallToolResult:    *mcp.NewToolResultText(string(bs)),
+		StructuredContent: content,
+	}
+}
+
+func describeTool(ctx context.Context, svc mcpblade.Service, req mcp.CallToolRequest) *CallToolResult {
+	name, err := req.RequireString("name")
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	tools, err := svc.ListTools(ctx)
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	for _, tool := range tools {
+		if tool.Name == name {
+			return structuredResult(tool)
+		}
+	}
+
+	return &CallToolResult{CallToolResult: *mcp.NewToolResultError(mcpblade.ErrToolNotFound.Error())}
+}
+
+func invokeTool(ctx context.Context, svc mcpblade.Service, req mcp.CallToolRequest) *CallToolResult {
+	name, err := req.RequireString("name")
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	var arguments map[string]any
+	if args, ok := req.GetArguments()["arguments"].(map[string]any); ok {
+		arguments = args
+	}
+
+	forwardReq := mcp.CallToolRequest{
+		Request: req.Request,
+		Params: mcp.CallToolParams{
+			Name:      name,
+			Arguments: arguments,
+			Meta:      req.Params.Meta,
+		},
+	}
+
+	result, err := svc.Forward(ctx, forwardReq)
+	if err != nil {
+		return &CallToolResult{CallToolResult: *mcp.NewToolResultError(err.Error())}
+	}
+
+	return &CallToolResult{CallToolResult: *result}
+}
+
+// callMetaTool handles a call to a built-in meta-tool advertised by the
+// endpoint, and reports false if the call is for a backend tool instead.
+// search_tools is always advertised, describe_tool and invoke_tool only in
+// lazy mode, so that backend tools of the same names remain callable
+// otherwise.
+func callMetaTool(ctx context.Context, svc mcpblade.Service, req mcp.CallToolRequest, lazy bool) (*CallToolResult, bool) {
+	if !isAggregated(ctx) {
+		return nil, false
+	}
+
+	switch req.Params.Name {
+	case SearchToolsToolName:
+		return searchTools(ctx, svc, req), true
+
+	case DescribeToolToolName:
+		if !lazy {
+			return nil, false
+		}
+
+		return describeTool(ctx, svc, req), true
+
+	case InvokeToolToolName:
+		if !lazy {
+			return nil, false
+		}
+
+		return invokeTool(ctx, svc, req), true
+
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,325 @@
+package mcpblade
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ConfigFormat is the format of a config file.
+type ConfigFormat string
+
+const (
+	// ConfigFormatMCPBlade is the YAML config of MCPBlade.
+	ConfigFormatMCPBlade ConfigFormat = "mcpblade"
+
+	// ConfigFormatClaudeDesktop is the claude_desktop_config.json of Claude
+	// Desktop, with the servers under mcpServers.
+	ConfigFormatClaudeDesktop ConfigFormat = "claude"
+
+	// ConfigFormatVSCode is the .vscode/mcp.json of VS Code, with the servers
+	// under servers.
+	ConfigFormatVSCode ConfigFormat = "vscode"
+)
+
+// MCPJSONServer is a server entry of an mcp.json file. The environment is a
+// map, and the transport is given by type, or implied by command or url.
+type MCPJSONServer struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// MCPServerConfig converts the entry to the config of an MCP server.
+func (s MCPJSONServer) MCPServerConfig() (MCPServerConfig, error) {
+	var transport TransportType
+
+	switch s.Type {
+	case "":
+		transport = TransportTypeStdio
+		if s.Command == "" && s.URL != "" {
+			transport = TransportTypeStreamableHTTP
+		}
+
+	case "stdio":
+		transport = TransportTypeStdio
+
+	case "sse":
+		transport = TransportTypeSSE
+
+	case "http", "streamable-http", "streamableHttp":
+		transport = TransportTypeStreamableHTTP
+
+	default:
+		return MCPServerConfig{}, ErrUnsupportedTransportType
+	}
+
+	var env []string
+	for _, key := range slices.Sorted(maps.Keys(s.Env)) {
+		env = append(env, key+"="+s.Env[key])
+	}
+
+	return MCPServerConfig{
+		Transport:   transport,
+		Command:     s.Command,
+		URL:         s.URL,
+		Arguments:   s.Args,
+		Environment: env,
+		Headers:     s.Headers,
+	}, nil
+}
+
+// NewMCPJSONServer converts the config of an MCP server to an mcp.json entry
+// of the given format. Only the stdio, sse and streamable-http transports can
+// be converted, without their TLS, proxy, timeout and OAuth settings.
+func NewMCPJSONServer(config MCPServerConfig, format ConfigFormat) (MCPJSONServer, error) {
+	s := MCPJSONServer{
+		Command: config.Command,
+		Args:    config.Arguments,
+		URL:     config.URL,
+		Headers: config.Headers,
+	}
+
+	switch config.Transport {
+	case TransportTypeStdio:
+		// VS Code requires the type of every server
+		if format == ConfigFormatVSCode {
+			s.Type = "stdio"
+		}
+
+	case TransportTypeSSE:
+		s.Type = "sse"
+
+	case TransportTypeStreamableHTTP:
+		s.Type = "http"
+
+	default:
+		return MCPJSONServer{}, ErrUnsupportedTransportType
+	}
+
+	if len(config.Environment) > 0 {
+		s.Env = make(map[string]string, len(config.Environment))
+
+		for _, kv := range config.Environment {
+			key, value, _ := strings.Cut(kv, "=")
+			s.Env[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// DetectConfigFormat detects the format of a config file by its extension
+// and its shape. YAML files are MCPBlade configs, while a JSON file is an
+// mcp.json file unless its servers have a transport. A .json file that is not
+// valid JSON is an mcp.json file, so that ParseMCPJSON reports the error.
+func DetectConfigFormat(path string, data []byte) ConfigFormat {
+	isJSON := false
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ConfigFormatMCPBlade
+
+	case ".json":
+		// A new mcp.json file
+		if len(bytes.TrimSpace(data)) == 0 {
+			return ConfigFormatClaudeDesktop
+		}
+
+		isJSON = true
+	}
+
+	var doc map[string]json.RawMessage
+	if err := unmarshalJSONC(data, &doc); err != nil {
+		if isJSON {
+			return ConfigFormatClaudeDesktop
+		}
+
+		return ConfigFormatMCPBlade
+	}
+
+	if _, ok := doc["servers"]; ok {
+		return ConfigFormatVSCode
+	}
+
+	raw, ok := doc["mcpServers"]
+	if !ok {
+		return ConfigFormatMCPBlade
+	}
+
+	var servers map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &servers); err != nil {
+		return ConfigFormatMCPBlade
+	}
+
+	for _, server := range servers {
+		if _, ok := server["transport"]; ok {
+			return ConfigFormatMCPBlade
+		}
+	}
+
+	return ConfigFormatClaudeDesktop
+}
+
+// ParseMCPJSON parses the servers of an mcp.json file of the given format,
+// which may have comments and trailing commas like the JSONC of VS Code.
+func ParseMCPJSON(data []byte, format ConfigFormat) (map[string]MCPServerConfig, error) {
+	var doc map[string]json.RawMessage
+	if err := unmarshalJSONC(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var entries map[string]MCPJSONServer
+	if raw, ok := doc[mcpJSONServersKey(format)]; ok {
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, err
+		}
+	}
+
+	servers := make(map[string]MCPServerConfig, len(entries))
+	for id, entry := range entries {
+		config, err := entry.MCPServerConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		servers[id] = config
+	}
+
+	return servers, nil
+}
+
+// ExportMCPJSON replaces the servers of an mcp.json file of the given format,
+// keeping its other keys, e.g. the inputs of VS Code. A new file is created
+// from empty data. The comments of a JSONC file are not kept.
+func ExportMCPJSON(data []byte, servers map[string]MCPServerConfig, format ConfigFormat) ([]byte, error) {
+	doc := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := unmarshalJSONC(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make(map[string]MCPJSONServer, len(servers))
+	for id, config := range servers {
+		entry, err := NewMCPJSONServer(config, format)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[id] = entry
+	}
+
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		doc = make(map[string]json.RawMessage)
+	}
+
+	doc[mcpJSONServersKey(format)] = raw
+
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func mcpJSONServersKey(format ConfigFormat) string {
+	if format == ConfigFormatVSCode {
+		return "servers"
+	}
+
+	return "mcpServers"
+}
+
+// unmarshalJSONC decodes JSON with the comments and trailing commas of JSONC,
+// and reports syntax errors with their line and column.
+func unmarshalJSONC(data []byte, v any) error {
+	data = stripJSONC(data)
+
+	err := json.Unmarshal(data, v)
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		prefix := data[:syntaxErr.Offset]
+
+		line := bytes.Count(prefix, []byte("\n")) + 1
+		column := len(prefix) - bytes.LastIndexByte(prefix, '\n') - 1
+
+		return errors.New("invalid JSON at line " + strconv.Itoa(line) +
+			", column " + strconv.Itoa(column) + ": " + syntaxErr.Error())
+	}
+
+	return err
+}
+
+// stripJSONC blanks out the comments and trailing commas of JSONC, keeping
+// the offsets of the remaining JSON.
+func stripJSONC(data []byte) []byte {
+	out := bytes.Clone(data)
+
+	// The last comma, which is trailing if a } or ] follows it
+	comma := -1
+
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			comma = -1
+
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := len(out)
+			if n := bytes.Index(out[i+2:], []byte("*/")); n >= 0 {
+				end = i + 2 + n + 2
+			}
+
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+
+			i--
+
+		case c == ',':
+			comma = i
+
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				out[comma] = ' '
+			}
+
+			comma = -1
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+
+		default:
+			comma = -1
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,286 @@
+package mcpblade
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/mcpblade/persistence/chromem"
+	"github.com/flarexio/mcpblade/vector"
+)
+
+func TestDetectConfigFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	claude := []byte(`{"mcpServers": {"time": {"command": "uvx", "args": ["mcp-server-time"]}}}`)
+	vscode := []byte(`{"inputs": [], "servers": {"time": {"type": "stdio", "command": "uvx"}}}`)
+	native := []byte(`{"mcpServers": {"time": {"transport": "stdio", "command": "uvx"}}}`)
+
+	assert.Equal(ConfigFormatClaudeDesktop, DetectConfigFormat("claude_desktop_config.json", claude))
+	assert.Equal(ConfigFormatVSCode, DetectConfigFormat(".vscode/mcp.json", vscode))
+	assert.Equal(ConfigFormatMCPBlade, DetectConfigFormat("config.json", native))
+	assert.Equal(ConfigFormatMCPBlade, DetectConfigFormat("config.yaml", claude))
+	assert.Equal(ConfigFormatMCPBlade, DetectConfigFormat("config", []byte("mcpServers: {}")))
+	assert.Equal(ConfigFormatClaudeDesktop, DetectConfigFormat("mcp.json", nil))
+
+	// VS Code allows comments and trailing commas
+	jsonc := []byte(`{
+  // Servers of the workspace
+  "servers": {"time": {"type": "stdio", "command": "uvx",},},
+}`)
+
+	assert.Equal(ConfigFormatVSCode, DetectConfigFormat(".vscode/mcp.json", jsonc))
+
+	// A .json file is never taken for YAML
+	assert.Equal(ConfigFormatClaudeDesktop, DetectConfigFormat("mcp.json", []byte(`{"servers": `)))
+}
+
+func TestParseMCPJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []byte(`{
+  "servers": {
+    "time": {
+      "type": "stdio",
+      "command": "uvx",
+      "args": ["mcp-server-time"],
+      "env": {"TZ": "Asia/Taipei", "LANG": "en_US.UTF-8"}
+    },
+    "remote": {"type": "http", "url": "https://example.com/mcp"},
+    "legacy": {"type": "sse", "url": "https://example.com/sse"}
+  }
+}`)
+
+	servers, err := ParseMCPJSON(data, ConfigFormatVSCode)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(MCPServerConfig{
+		Transport:   TransportTypeStdio,
+		Command:     "uvx",
+		Arguments:   []string{"mcp-server-time"},
+		Environment: []string{"LANG=en_US.UTF-8", "TZ=Asia/Taipei"},
+	}, servers["time"])
+
+	assert.Equal(TransportTypeStreamableHTTP, servers["remote"].Transport)
+	assert.Equal(TransportTypeSSE, servers["legacy"].Transport)
+
+	// Claude Desktop implies the transport
+	data = []byte(`{"mcpServers": {"time": {"command": "uvx"}, "remote": {"url": "https://example.com/mcp"}}}`)
+
+	servers, err = ParseMCPJSON(data, ConfigFormatClaudeDesktop)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(TransportTypeStdio, servers["time"].Transport)
+	assert.Equal(TransportTypeStreamableHTTP, servers["remote"].Transport)
+
+	data = []byte(`{"mcpServers": {"time": {"type": "grpc"}}}`)
+
+	_, err = ParseMCPJSON(data, ConfigFormatClaudeDesktop)
+	assert.ErrorIs(err, ErrUnsupportedTransportType)
+
+	data = []byte(`{
+  /* Inputs prompted by VS Code */
+  "inputs": [{"id": "token", "description": "// not a comment"},],
+  "servers": {
+    "time": {"type": "stdio", "command": "uvx"}, // Local
+  },
+}`)
+
+	servers, err = ParseMCPJSON(data, ConfigFormatVSCode)
+	if assert.NoError(err) {
+		assert.Equal("uvx", servers["time"].Command)
+	}
+
+	data = []byte(`{
+  "servers": {
+    "time": {"type": "stdio" "command": "uvx"}
+  }
+}`)
+
+	_, err = ParseMCPJSON(data, ConfigFormatVSCode)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "invalid JSON at line 3, column 30")
+	}
+}
+
+func TestExportMCPJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	servers := map[string]MCPServerConfig{
+		"time": {
+			Transport:   TransportTypeStdio,
+			Command:     "uvx",
+			Arguments:   []string{"mcp-server-time"},
+			Environment: []string{"TZ=Asia/Taipei"},
+		},
+		"remote": {
+			Transport: TransportTypeStreamableHTTP,
+			URL:       "https://example.com/mcp",
+		},
+	}
+
+	data, err := ExportMCPJSON([]byte(`{"inputs": [{"id": "token"}], "servers": {}}`), servers, ConfigFormatVSCode)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var doc struct {
+		Inputs  []map[string]string      `json:"inputs"`
+		Servers map[string]MCPJSONServer `json:"servers"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(doc.Inputs, 1)
+	assert.Equal("stdio", doc.Servers["time"].Type)
+	assert.Equal(map[string]string{"TZ": "Asia/Taipei"}, doc.Servers["time"].Env)
+	assert.Equal("http", doc.Servers["remote"].Type)
+
+	// The servers survive a round trip
+	parsed, err := ParseMCPJSON(data, ConfigFormatVSCode)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(servers, parsed)
+
+	servers["edge"] = MCPServerConfig{
+		Transport: TransportTypeNATS,
+		Subject:   "edges.edge01.mcp",
+	}
+
+	_, err = ExportMCPJSON(nil, servers, ConfigFormatClaudeDesktop)
+	assert.ErrorIs(err, ErrUnsupportedTransportType)
+
+	// The other keys of a JSONC file are kept
+	delete(servers, "edge")
+
+	data, err = ExportMCPJSON([]byte(`{"inputs": [], // Prompted
+  "servers": {},
+}`), servers, ConfigFormatVSCode)
+
+	if assert.NoError(err) {
+		var doc map[string]json.RawMessage
+		if assert.NoError(json.Unmarshal(data, &doc)) {
+			assert.JSONEq(`[]`, string(doc["inputs"]))
+			assert.Contains(string(doc["servers"]), `"time"`)
+		}
+	}
+}
+
+func TestLoadConfigMCPJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "claude_desktop_config.json")
+
+	input := `{"globalShortcut": "", "mcpServers": {"time": {"command": "uvx"}}}`
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("uvx", cfg.MCPServers["time"].Command)
+
+	// Write-back keeps the format and the other settings
+	cfg.MCPServers["remote"] = MCPServerConfig{
+		Transport: TransportTypeSSE,
+		URL:       "https://example.com/sse",
+	}
+
+	if err := SaveMCPServers(path, cfg.MCPServers); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Contains(string(data), `"globalShortcut"`)
+	assert.Equal(ConfigFormatClaudeDesktop, DetectConfigFormat(path, data))
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(cfg.MCPServers, loaded.MCPServers)
+}
+
+func TestNewServiceFromMCPJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	ts := httptest.NewServer(server.NewStreamableHTTPServer(backend))
+	defer ts.Close()
+
+	files := map[string]string{
+		"claude_desktop_config.json": `{"mcpServers": {"remote": {"url": "` + ts.URL + `/mcp"}}}`,
+		"mcp.json":                   `{"servers": {"remote": {"type": "http", "url": "` + ts.URL + `/mcp"}}}`,
+	}
+
+	for name, input := range files {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		cfg, err := LoadConfig(path)
+		if !assert.NoError(err, name) {
+			continue
+		}
+
+		assert.Equal(DefaultVectorCollection, cfg.Vector.Collection, name)
+
+		// The same startup path as mcpblade, with offline embeddings
+		cfg.Vector.Embedding.Provider = vector.EmbeddingProviderHash
+
+		db, err := chromem.NewChromemVectorDB(cfg.Vector)
+		if !assert.NoError(err, name) {
+			continue
+		}
+
+		ctx := context.Background()
+
+		svc, err := NewService(ctx, cfg, db)
+		if !assert.NoError(err, name) {
+			continue
+		}
+
+		tools, err := svc.SearchTools(ctx, "echo", 1)
+		if assert.NoError(err, name) && assert.Len(tools, 1, name) {
+			assert.Equal("echo", tools[0].Name, name)
+		}
+
+		svc.Close()
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -18,15 +21,18 @@ import (
 )
 
 var (
-	ErrUnsupportedTransportType           = errors.New("unsupported transport type")
-	ErrInvalidServerID                    = errors.New("invalid server ID")
-	ErrServerAlreadyExists                = errors.New("server already exists")
-	ErrServerNotFound                     = errors.New("server not found")
-	ErrNoToolsFound                       = errors.New("no tools found")
-	ErrToolNotFound                       = errors.New("tool not found")
-	ErrVectorDBNotSet                     = errors.New("vector database not set")
-	ErrInvalidToolDocument                = errors.New("invalid tool document")
-	ErrUnsupportedPersistentServerRemoval = errors.New("removal of persistent servers is not supported")
+	ErrUnsupportedTransportType = errors.New("unsupported transport type")
+	ErrInvalidServerID          = errors.New("invalid server ID")
+	ErrServerAlreadyExists      = errors.New("server already exists")
+	ErrServerNotFound           = errors.New("server not found")
+	ErrNoToolsFound             = errors.New("no tools found")
+	ErrToolNotFound             = errors.New("tool not found")
+	ErrPromptNotFound           = errors.New("prompt not found")
+	ErrVectorDBNotSet           = errors.New("vector database not set")
+	ErrInvalidToolDocument      = errors.New("invalid tool document")
+	ErrInvalidResourceURI       = errors.New("invalid resource URI")
+	ErrResourceNotFound         = errors.New("resource not found")
+	ErrVariablesNotAllowed      = errors.New("variables are only allowed in the config file")
 )
 
 type ContextKey string
@@ -34,14 +40,42 @@ type ContextKey string
 const (
 	EdgeID   ContextKey = "edge_id"
 	ServerID ContextKey = "server_id"
+
+	// Progress holds the ProgressReporter of a tool call
+	Progress ContextKey = "progress"
 )
 
 type Config struct {
 	MCPServers      map[string]MCPServerConfig `yaml:"mcpServers"`
 	CacheRefreshTTL time.Duration              `yaml:"cacheRefreshTTL"`
 	Vector          vector.Config              `yaml:"vector"`
+
+	// Positions of the fields and unknown fields of a YAML config, see
+	// Validate
+	positions     map[string]position
+	unknownFields []string
 }
 
+const (
+	DefaultCacheRefreshTTL  = 5 * time.Minute
+	DefaultVectorCollection = "tools"
+)
+
+// NotificationHandler handles a notification relayed to downstream clients.
+// serverID is empty for notifications about the aggregated servers, and set
+// to the temporary server ID otherwise.
+type NotificationHandler func(serverID string, notification mcp.JSONRPCNotification)
+
+// Notification methods not defined by mcp-go
+const (
+	MethodNotificationProgress  = "notifications/progress"
+	MethodNotificationCancelled = "notifications/cancelled"
+)
+
+// ProgressReporter receives the progress notifications of a forwarded tool
+// call, carrying the progress token given by the caller.
+type ProgressReporter func(notification mcp.JSONRPCNotification)
+
 type TransportType string
 
 const (
@@ -59,6 +93,13 @@ const (
 	RestartPolicyNever     RestartPolicy = "never"
 )
 
+const (
+	DefaultMaxRestarts       = 5
+	DefaultRestartBackoff    = time.Second
+	DefaultMaxRestartBackoff = time.Minute
+	DefaultTTL               = 5 * time.Minute
+)
+
 type Duration time.Duration
 
 func (d Duration) Duration() time.Duration {
@@ -86,9 +127,8 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (d Duration) MarshalYAML() ([]byte, error) {
-	str := d.Duration().String()
-	return yaml.Marshal(str)
+func (d Duration) MarshalYAML() (any, error) {
+	return d.Duration().String(), nil
 }
 
 func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
@@ -109,12 +149,174 @@ func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
 
 type MCPServerConfig struct {
 	Transport     TransportType `json:"transport" yaml:"transport"`
-	Command       string        `json:"command" yaml:"command"`
-	URL           string        `json:"url" yaml:"url"`
-	Arguments     []string      `json:"args" yaml:"args"`
-	Environment   []string      `json:"env" yaml:"env"`
-	RestartPolicy RestartPolicy `json:"restart" yaml:"restart"`
-	TTL           Duration      `json:"ttl" yaml:"ttl"`
+	Command       string        `json:"command" yaml:"command,omitempty"`
+	URL           string        `json:"url" yaml:"url,omitempty"`
+	Subject       string        `json:"subject" yaml:"subject,omitempty"`
+	Arguments     []string      `json:"args" yaml:"args,omitempty"`
+	Environment   []string      `json:"env" yaml:"env,omitempty"`
+	RestartPolicy RestartPolicy `json:"restart" yaml:"restart,omitempty"`
+	MaxRestarts   int           `json:"maxRestarts" yaml:"maxRestarts,omitempty"`
+	TTL           Duration      `json:"ttl" yaml:"ttl,omitempty"`
+
+	// HTTP settings of the sse and streamable-http transports
+	Headers map[string]string `json:"headers" yaml:"headers,omitempty"`
+	TLS     *TLSConfig        `json:"tls" yaml:"tls,omitempty"`
+	Proxy   string            `json:"proxy" yaml:"proxy,omitempty"`
+	Timeout Duration          `json:"timeout" yaml:"timeout,omitempty"`
+	OAuth   *OAuthConfig      `json:"oauth" yaml:"oauth,omitempty"`
+}
+
+// TLSConfig configures the TLS connections to a remote server. The CA, the
+// client certificate and its key are paths to PEM files.
+type TLSConfig struct {
+	CA       string `json:"ca,omitempty" yaml:"ca,omitempty"`
+	Cert     string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`
+	Insecure bool   `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+}
+
+// ShouldRestart reports whether a failed server should be restarted after
+// the given number of consecutive restart attempts. The always policy has no
+// restart budget unless MaxRestarts is set.
+func (cfg MCPServerConfig) ShouldRestart(attempts int) bool {
+	switch cfg.RestartPolicy {
+	case RestartPolicyAlways:
+		return cfg.MaxRestarts <= 0 || attempts < cfg.MaxRestarts
+
+	case RestartPolicyOnFailure:
+		maxRestarts := cfg.MaxRestarts
+		if maxRestarts <= 0 {
+			maxRestarts = DefaultMaxRestarts
+		}
+
+		return attempts < maxRestarts
+
+	default:
+		return false
+	}
+}
+
+// Interpolate expands the variables and secret files referenced by the
+// command, URL, subject, arguments, environment, headers, TLS files, proxy and
+// OAuth client, see Interpolate.
+func (cfg MCPServerConfig) Interpolate() (MCPServerConfig, error) {
+	var err error
+
+	if cfg.Command, err = Interpolate(cfg.Command); err != nil {
+		return cfg, err
+	}
+
+	if cfg.URL, err = Interpolate(cfg.URL); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Subject, err = Interpolate(cfg.Subject); err != nil {
+		return cfg, err
+	}
+
+	cfg.Arguments = slices.Clone(cfg.Arguments)
+	for i, arg := range cfg.Arguments {
+		if cfg.Arguments[i], err = Interpolate(arg); err != nil {
+			return cfg, err
+		}
+	}
+
+	cfg.Environment = slices.Clone(cfg.Environment)
+	for i, env := range cfg.Environment {
+		if cfg.Environment[i], err = Interpolate(env); err != nil {
+			return cfg, err
+		}
+	}
+
+	cfg.Headers = maps.Clone(cfg.Headers)
+	for key, value := range cfg.Headers {
+		if cfg.Headers[key], err = Interpolate(value); err != nil {
+			return cfg, err
+		}
+	}
+
+	if cfg.TLS != nil {
+		tls := *cfg.TLS
+		for _, path := range []*string{&tls.CA, &tls.Cert, &tls.Key} {
+			if *path, err = Interpolate(*path); err != nil {
+				return cfg, err
+			}
+		}
+
+		cfg.TLS = &tls
+	}
+
+	if cfg.Proxy, err = Interpolate(cfg.Proxy); err != nil {
+		return cfg, err
+	}
+
+	if cfg.OAuth != nil {
+		oauth := *cfg.OAuth
+		for _, value := range []*string{&oauth.ClientID, &oauth.ClientSecret} {
+			if *value, err = Interpolate(*value); err != nil {
+				return cfg, err
+			}
+		}
+
+		cfg.OAuth = &oauth
+	}
+
+	return cfg, nil
+}
+
+// HasVariables reports whether any value of the config references a variable
+// or a secret file, or escapes a $, i.e. would change when interpolated.
+func (cfg MCPServerConfig) HasVariables() bool {
+	values := []string{cfg.Command, cfg.URL, cfg.Subject, cfg.Proxy}
+	values = append(values, cfg.Arguments...)
+	values = append(values, cfg.Environment...)
+	values = slices.AppendSeq(values, maps.Values(cfg.Headers))
+
+	if cfg.TLS != nil {
+		values = append(values, cfg.TLS.CA, cfg.TLS.Cert, cfg.TLS.Key)
+	}
+
+	if cfg.OAuth != nil {
+		values = append(values, cfg.OAuth.ClientID, cfg.OAuth.ClientSecret)
+	}
+
+	for _, value := range values {
+		if strings.Contains(value, "${") || strings.Contains(value, "$$") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SameConnection reports whether both configs connect to the server in the
+// same way, so that a running client can be kept when switching between them.
+func (cfg MCPServerConfig) SameConnection(other MCPServerConfig) bool {
+	return cfg.Transport == other.Transport &&
+		cfg.Command == other.Command &&
+		cfg.URL == other.URL &&
+		cfg.Subject == other.Subject &&
+		slices.Equal(cfg.Arguments, other.Arguments) &&
+		slices.Equal(cfg.Environment, other.Environment) &&
+		maps.Equal(cfg.Headers, other.Headers) &&
+		reflect.DeepEqual(cfg.TLS, other.TLS) &&
+		cfg.Proxy == other.Proxy &&
+		cfg.Timeout == other.Timeout &&
+		reflect.DeepEqual(cfg.OAuth, other.OAuth)
+}
+
+// RestartBackoff returns the delay before the given restart attempt,
+// doubling on every attempt up to DefaultMaxRestartBackoff.
+func (cfg MCPServerConfig) RestartBackoff(attempts int) time.Duration {
+	backoff := DefaultRestartBackoff
+	for i := 0; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= DefaultMaxRestartBackoff {
+			return DefaultMaxRestartBackoff
+		}
+	}
+
+	return backoff
 }
 
 type MCPServerInstance struct {
@@ -122,7 +324,15 @@ type MCPServerInstance struct {
 	Client client.MCPClient
 	Config MCPServerConfig
 
-	heartbeat atomic.Int64
+	// The interpolated config the client is connected with, to restart it
+	conn MCPServerConfig
+
+	heartbeat  atomic.Int64
+	restarts   atomic.Int32
+	restarting atomic.Bool
+
+	// Set once the instance is removed or replaced, to stop its pending restart
+	retired atomic.Bool
 }
 
 func (i *MCPServerInstance) Beat() {
@@ -134,8 +344,39 @@ func (i *MCPServerInstance) IsAlive() bool {
 		return false
 	}
 
+	ttl := i.Config.TTL.Duration()
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
 	lastBeat := time.Unix(0, i.heartbeat.Load())
-	return time.Since(lastBeat) < i.Config.TTL.Duration()
+	return time.Since(lastBeat) < ttl
+}
+
+// ResourceURIScheme is the scheme of namespaced resource URIs, which embed
+// the owning server so that reads can be routed to it.
+const ResourceURIScheme = "mcpblade"
+
+// NamespaceResourceURI returns the URI (or URI template) of a resource of the
+// given server as exposed by mcpblade, e.g. mcpblade://fs/file:///notes.txt.
+func NamespaceResourceURI(serverID string, uri string) string {
+	return ResourceURIScheme + "://" + serverID + "/" + uri
+}
+
+// ParseResourceURI splits a namespaced resource URI into the server ID and
+// the original resource URI.
+func ParseResourceURI(uri string) (serverID string, original string, err error) {
+	rest, ok := strings.CutPrefix(uri, ResourceURIScheme+"://")
+	if !ok {
+		return "", "", ErrInvalidResourceURI
+	}
+
+	serverID, original, ok = strings.Cut(rest, "/")
+	if !ok || serverID == "" || original == "" {
+		return "", "", ErrInvalidResourceURI
+	}
+
+	return serverID, original, nil
 }
 
 func ToolToDocument(tool mcp.Tool, serverID string) vector.Document {
@@ -187,3 +428,88 @@ func buildMetadata(tool mcp.Tool, serverID string) map[string]string {
 
 	return metadata
 }
+
+// ToolDiff describes how the tools cache changed between two refreshes.
+type ToolDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// DiffToolDocuments compares two snapshots of tool documents keyed by routed
+// tool name. A tool is changed when its document ID, which is derived from
+// the tool definition, differs between the snapshots.
+func DiffToolDocuments(prev, next map[string]vector.Document) ToolDiff {
+	var diff ToolDiff
+
+	for name, doc := range next {
+		prevDoc, ok := prev[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+
+		if prevDoc.ID != doc.ID {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+
+	return diff
+}
+
+// ServerDiff describes how the persistent servers changed between two configs.
+// Changed servers have to be reconnected, whereas updated servers only changed
+// settings that do not affect the connection, e.g. the restart policy.
+type ServerDiff struct {
+	Added     []string
+	Removed   []string
+	Changed   []string
+	Updated   []string
+	Unchanged []string
+
+	// Servers that could not be started or restarted, set by a reload
+	Failed []string
+}
+
+// DiffMCPServers compares two sets of server configs keyed by server ID.
+func DiffMCPServers(prev, next map[string]MCPServerConfig) ServerDiff {
+	var diff ServerDiff
+
+	for id, cfg := range next {
+		prevCfg, ok := prev[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, id)
+		case !prevCfg.SameConnection(cfg):
+			diff.Changed = append(diff.Changed, id)
+		case !reflect.DeepEqual(prevCfg, cfg):
+			diff.Updated = append(diff.Updated, id)
+		default:
+			diff.Unchanged = append(diff.Unchanged, id)
+		}
+	}
+
+	for id := range prev {
+		if _, ok := next[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+	slices.Sort(diff.Updated)
+	slices.Sort(diff.Unchanged)
+
+	return diff
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
+
+	"github.com/flarexio/mcpblade/vector"
 )
 
 func TestMCPServerConfigJSONUnmarshal(t *testing.T) {
@@ -51,3 +53,183 @@ args:
 	assert.Equal("uvx", config.Command)
 	assert.Equal(time.Duration(0), config.TTL.Duration(), "permanent server should have TTL")
 }
+
+func TestMCPServerConfigShouldRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	never := MCPServerConfig{RestartPolicy: RestartPolicyNever}
+	assert.False(never.ShouldRestart(0))
+
+	unset := MCPServerConfig{}
+	assert.False(unset.ShouldRestart(0), "restart policy should default to never")
+
+	onFailure := MCPServerConfig{RestartPolicy: RestartPolicyOnFailure}
+	assert.True(onFailure.ShouldRestart(0))
+	assert.True(onFailure.ShouldRestart(DefaultMaxRestarts - 1))
+	assert.False(onFailure.ShouldRestart(DefaultMaxRestarts))
+
+	onFailure.MaxRestarts = 2
+	assert.True(onFailure.ShouldRestart(1))
+	assert.False(onFailure.ShouldRestart(2))
+
+	always := MCPServerConfig{RestartPolicy: RestartPolicyAlways}
+	assert.True(always.ShouldRestart(100))
+
+	// An explicit maxRestarts bounds the always policy as well
+	always.MaxRestarts = 3
+	assert.True(always.ShouldRestart(2))
+	assert.False(always.ShouldRestart(3))
+}
+
+func TestMCPServerConfigRestartBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	var config MCPServerConfig
+	assert.Equal(1*time.Second, config.RestartBackoff(0))
+	assert.Equal(2*time.Second, config.RestartBackoff(1))
+	assert.Equal(8*time.Second, config.RestartBackoff(3))
+	assert.Equal(DefaultMaxRestartBackoff, config.RestartBackoff(10))
+	assert.Equal(DefaultMaxRestartBackoff, config.RestartBackoff(1000))
+}
+
+func TestMCPServerInstanceIsAlive(t *testing.T) {
+	assert := assert.New(t)
+
+	instance := &MCPServerInstance{
+		Config: MCPServerConfig{
+			TTL: Duration(time.Minute),
+		},
+	}
+
+	assert.False(instance.IsAlive(), "instance without heartbeat should not be alive")
+
+	instance.Beat()
+	assert.True(instance.IsAlive())
+
+	instance.heartbeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	assert.False(instance.IsAlive(), "instance should expire after its TTL")
+
+	instance.Config.TTL = 0
+	assert.True(instance.IsAlive(), "zero TTL should fall back to the default TTL")
+
+	instance.heartbeat.Store(time.Now().Add(-DefaultTTL).UnixNano())
+	assert.False(instance.IsAlive())
+}
+
+func TestDiffToolDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	prev := map[string]vector.Document{
+		"get_current_time": {ID: "tool_a"},
+		"convert_time":     {ID: "tool_b"},
+		"removed_tool":     {ID: "tool_c"},
+	}
+
+	next := map[string]vector.Document{
+		"get_current_time": {ID: "tool_a"},
+		"convert_time":     {ID: "tool_b2"},
+		"added_tool":       {ID: "tool_d"},
+	}
+
+	diff := DiffToolDocuments(prev, next)
+	assert.Equal([]string{"added_tool"}, diff.Added)
+	assert.Equal([]string{"removed_tool"}, diff.Removed)
+	assert.Equal([]string{"convert_time"}, diff.Changed)
+
+	diff = DiffToolDocuments(nil, next)
+	assert.Len(diff.Added, 3)
+	assert.Empty(diff.Removed)
+	assert.Empty(diff.Changed)
+}
+
+func TestDiffMCPServers(t *testing.T) {
+	assert := assert.New(t)
+
+	prev := map[string]MCPServerConfig{
+		"time": {
+			Transport: TransportTypeStdio,
+			Command:   "uvx",
+			Arguments: []string{"mcp-server-time"},
+		},
+		"fetch": {
+			Transport: TransportTypeStdio,
+			Command:   "uvx",
+			Arguments: []string{"mcp-server-fetch"},
+		},
+		"remote": {
+			Transport: TransportTypeStreamableHTTP,
+			URL:       "https://example.com/mcp",
+		},
+		"auth": {
+			Transport: TransportTypeStreamableHTTP,
+			URL:       "https://example.com/mcp",
+			Headers:   map[string]string{"Authorization": "Bearer ${TOKEN}"},
+		},
+		"removed": {
+			Transport: TransportTypeSSE,
+			URL:       "https://example.com/sse",
+		},
+	}
+
+	next := map[string]MCPServerConfig{
+		"time": {
+			Transport: TransportTypeStdio,
+			Command:   "uvx",
+			Arguments: []string{"mcp-server-time"},
+		},
+		"fetch": {
+			Transport: TransportTypeStdio,
+			Command:   "uvx",
+			Arguments: []string{"mcp-server-fetch", "--ignore-robots-txt"},
+		},
+		"remote": {
+			Transport:     TransportTypeStreamableHTTP,
+			URL:           "https://example.com/mcp",
+			RestartPolicy: RestartPolicyAlways,
+		},
+		"auth": {
+			Transport: TransportTypeStreamableHTTP,
+			URL:       "https://example.com/mcp",
+			Headers:   map[string]string{"Authorization": "Bearer ${NEW_TOKEN}"},
+		},
+		"added": {
+			Transport: TransportTypeNATS,
+			Subject:   "edges.edge01.mcp",
+		},
+	}
+
+	diff := DiffMCPServers(prev, next)
+	assert.Equal([]string{"added"}, diff.Added)
+	assert.Equal([]string{"removed"}, diff.Removed)
+	assert.Equal([]string{"auth", "fetch"}, diff.Changed)
+	assert.Equal([]string{"remote"}, diff.Updated)
+	assert.Equal([]string{"time"}, diff.Unchanged)
+	assert.Empty(diff.Failed)
+
+	diff = DiffMCPServers(prev, nil)
+	assert.Len(diff.Removed, 5)
+	assert.Empty(diff.Added)
+}
+
+func TestParseResourceURI(t *testing.T) {
+	assert := assert.New(t)
+
+	uri := NamespaceResourceURI("fs", "file:///notes.txt")
+	assert.Equal("mcpblade://fs/file:///notes.txt", uri)
+
+	serverID, original, err := ParseResourceURI(uri)
+	if assert.NoError(err) {
+		assert.Equal("fs", serverID)
+		assert.Equal("file:///notes.txt", original)
+	}
+
+	for _, invalid := range []string{
+		"file:///notes.txt",
+		"mcpblade://fs",
+		"mcpblade:///file:///notes.txt",
+		"mcpblade://fs/",
+	} {
+		_, _, err := ParseResourceURI(invalid)
+		assert.ErrorIs(err, ErrInvalidResourceURI, invalid)
+	}
+}
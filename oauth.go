@@ -0,0 +1,332 @@
+package mcpblade
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAuthorizationRequired = errors.New("authorization required")
+	ErrAuthorizationNotFound = errors.New("authorization not found or expired")
+	ErrOAuthNotConfigured    = errors.New("oauth not configured")
+	ErrRedirectURINotSet     = errors.New("oauth redirect URI not set")
+)
+
+// authorizationTTL is how long an authorization URL can be completed.
+const authorizationTTL = 10 * time.Minute
+
+// OAuthConfig enables the MCP authorization flow for a remote server. Without
+// a client ID, mcpblade registers itself as a client dynamically.
+type OAuthConfig struct {
+	ClientID     string   `json:"clientID,omitempty" yaml:"clientID,omitempty"`
+	ClientSecret string   `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	RedirectURI  string   `json:"redirectURI,omitempty" yaml:"redirectURI,omitempty"`
+	MetadataURL  string   `json:"metadataURL,omitempty" yaml:"metadataURL,omitempty"`
+}
+
+// WithOAuth stores the OAuth tokens of remote servers in dir, and sets the
+// default redirect URI, which completes authorizations with
+// Service.CompleteAuthorization. Without it, tokens are kept in memory.
+func WithOAuth(dir string, redirectURI string) ServiceOption {
+	return func(svc *service) {
+		svc.oauthDir = dir
+		svc.redirectURI = redirectURI
+	}
+}
+
+// oauthState is the client registration and the token of a remote server.
+type oauthState struct {
+	URL          string           `json:"url"`
+	ClientID     string           `json:"client_id,omitempty"`
+	ClientSecret string           `json:"client_secret,omitempty"`
+	Token        *transport.Token `json:"token,omitempty"`
+}
+
+// oauthStore keeps the oauthState of a remote server, in a file if path is
+// set. It implements transport.TokenStore.
+type oauthStore struct {
+	path  string
+	state oauthState
+	mu    sync.RWMutex
+}
+
+func newOAuthStore(dir string, serverURL string) (*oauthStore, error) {
+	store := &oauthStore{
+		state: oauthState{URL: serverURL},
+	}
+
+	if dir == "" {
+		return store, nil
+	}
+
+	// Tokens belong to the server URL rather than to the server ID
+	sum := sha256.Sum256([]byte(serverURL))
+	store.path = filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+
+		return nil, err
+	}
+
+	var state oauthState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	if state.URL == serverURL {
+		store.state = state
+	}
+
+	return store, nil
+}
+
+func (s *oauthStore) GetToken() (*transport.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.state.Token == nil {
+		return nil, ErrAuthorizationRequired
+	}
+
+	token := *s.state.Token
+	return &token, nil
+}
+
+func (s *oauthStore) SaveToken(token *transport.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Token = token
+	return s.save()
+}
+
+func (s *oauthStore) client() (string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state.ClientID, s.state.ClientSecret
+}
+
+func (s *oauthStore) saveClient(id, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.ClientID = id
+	s.state.ClientSecret = secret
+	return s.save()
+}
+
+// save writes the state, readable by the owner only. The caller must hold mu.
+func (s *oauthStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(s.path, data, 0600)
+}
+
+// authorization is an authorization flow waiting for its callback.
+type authorization struct {
+	serverID string
+	handler  *transport.OAuthHandler
+	verifier string
+	expires  time.Time
+}
+
+// oauthStore returns the store of a remote server, shared by its transports
+// and authorizations.
+func (svc *service) oauthStore(serverURL string) (*oauthStore, error) {
+	svc.oauthMutex.Lock()
+	defer svc.oauthMutex.Unlock()
+
+	if store, ok := svc.oauthStores[serverURL]; ok {
+		return store, nil
+	}
+
+	store, err := newOAuthStore(svc.oauthDir, serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.oauthStores[serverURL] = store
+	return store, nil
+}
+
+// oauthConfig returns the OAuth config of the transport of an interpolated
+// remote server config.
+func (svc *service) oauthConfig(config MCPServerConfig) (transport.OAuthConfig, error) {
+	store, err := svc.oauthStore(config.URL)
+	if err != nil {
+		return transport.OAuthConfig{}, err
+	}
+
+	clientID, clientSecret := config.OAuth.ClientID, config.OAuth.ClientSecret
+	if clientID == "" {
+		clientID, clientSecret = store.client()
+	}
+
+	redirectURI := config.OAuth.RedirectURI
+	if redirectURI == "" {
+		redirectURI = svc.redirectURI
+	}
+
+	return transport.OAuthConfig{
+		ClientID:              clientID,
+		ClientSecret:          clientSecret,
+		RedirectURI:           redirectURI,
+		Scopes:                config.OAuth.Scopes,
+		TokenStore:            store,
+		AuthServerMetadataURL: config.OAuth.MetadataURL,
+		PKCEEnabled:           true,
+	}, nil
+}
+
+func (svc *service) AuthorizeMCPServer(ctx context.Context, id string) (string, error) {
+	svc.persistentMutex.RLock()
+	config, ok := svc.cfg.MCPServers[id]
+	svc.persistentMutex.RUnlock()
+
+	if !ok {
+		return "", ErrServerNotFound
+	}
+
+	if config.OAuth == nil {
+		return "", ErrOAuthNotConfigured
+	}
+
+	config, err := config.Interpolate()
+	if err != nil {
+		return "", err
+	}
+
+	oauthConfig, err := svc.oauthConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	if oauthConfig.RedirectURI == "" {
+		return "", ErrRedirectURINotSet
+	}
+
+	serverURL, err := url.Parse(config.URL)
+	if err != nil {
+		return "", err
+	}
+
+	handler := transport.NewOAuthHandler(oauthConfig)
+	handler.SetBaseURL(serverURL.Scheme + "://" + serverURL.Host)
+
+	if oauthConfig.ClientID == "" {
+		if err := handler.RegisterClient(ctx, "mcpblade"); err != nil {
+			return "", redactError(err)
+		}
+
+		store := oauthConfig.TokenStore.(*oauthStore)
+		if err := store.saveClient(handler.GetClientID(), handler.GetClientSecret()); err != nil {
+			return "", err
+		}
+	}
+
+	verifier, err := transport.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	state, err := transport.GenerateState()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := handler.GetAuthorizationURL(ctx, state, transport.GenerateCodeChallenge(verifier))
+	if err != nil {
+		return "", redactError(err)
+	}
+
+	svc.oauthMutex.Lock()
+	defer svc.oauthMutex.Unlock()
+
+	for state, a := range svc.authorizations {
+		if time.Now().After(a.expires) {
+			delete(svc.authorizations, state)
+		}
+	}
+
+	svc.authorizations[state] = &authorization{
+		serverID: id,
+		handler:  handler,
+		verifier: verifier,
+		expires:  time.Now().Add(authorizationTTL),
+	}
+
+	return authURL, nil
+}
+
+func (svc *service) CompleteAuthorization(ctx context.Context, state string, code string) (string, error) {
+	svc.oauthMutex.Lock()
+	a, ok := svc.authorizations[state]
+	delete(svc.authorizations, state)
+	svc.oauthMutex.Unlock()
+
+	if !ok || time.Now().After(a.expires) {
+		return "", ErrAuthorizationNotFound
+	}
+
+	err := a.handler.ProcessAuthorizationResponse(ctx, code, state, a.verifier)
+	if err != nil {
+		return "", redactError(err)
+	}
+
+	// Start the server, which was waiting for the authorization
+	svc.persistentMutex.RLock()
+	_, running := svc.persistentInstances[a.serverID]
+	config, configured := svc.cfg.MCPServers[a.serverID]
+	svc.persistentMutex.RUnlock()
+
+	if !configured || running {
+		return a.serverID, nil
+	}
+
+	conn, err := config.Interpolate()
+	if err != nil {
+		return a.serverID, err
+	}
+
+	err = svc.registerMCPServer(ctx, a.serverID, config, conn, true, false)
+	if err != nil && !errors.Is(err, ErrServerAlreadyExists) {
+		return a.serverID, err
+	}
+
+	svc.refreshPersistentServer(ctx, a.serverID)
+
+	svc.log.Info("authorized persistent MCP server",
+		zap.String("action", "complete_authorization"),
+		zap.String("server_id", a.serverID),
+	)
+
+	return a.serverID, nil
+}
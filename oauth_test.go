@@ -0,0 +1,362 @@
+package mcpblade
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// authServer is a stand-in OAuth authorization server, which is also the
+// protected MCP backend. It grants every authorization, registers every
+// client, and checks the PKCE verifiers.
+type authServer struct {
+	*httptest.Server
+
+	challenges map[string]string // code to challenge
+	tokens     map[string]bool   // valid access tokens
+	refreshes  int
+	issued     int
+	mu         sync.Mutex
+}
+
+func newAuthServer() *authServer {
+	as := &authServer{
+		challenges: make(map[string]string),
+		tokens:     make(map[string]bool),
+	}
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	streamable := server.NewStreamableHTTPServer(backend)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/oauth-protected-resource", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"resource":              as.URL,
+			"authorization_servers": []string{as.URL},
+		})
+	})
+
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                           as.URL,
+			"authorization_endpoint":           as.URL + "/authorize",
+			"token_endpoint":                   as.URL + "/token",
+			"registration_endpoint":            as.URL + "/register",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+
+	mux.HandleFunc("/register", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"client_id": "registered-client",
+		})
+	})
+
+	// The operator consents at once
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != "registered-client" || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "invalid_request", http.StatusBadRequest)
+			return
+		}
+
+		as.mu.Lock()
+		code := "code-" + q.Get("state")
+		as.challenges[code] = q.Get("code_challenge")
+		as.mu.Unlock()
+
+		redirect, _ := url.Parse(q.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		as.mu.Lock()
+		defer as.mu.Unlock()
+
+		switch r.FormValue("grant_type") {
+		case "authorization_code":
+			challenge, ok := as.challenges[r.FormValue("code")]
+			delete(as.challenges, r.FormValue("code"))
+
+			sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+			if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+
+		case "refresh_token":
+			if r.FormValue("refresh_token") != "refresh-token" {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+
+			as.refreshes++
+
+		default:
+			http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+			return
+		}
+
+		as.issued++
+		token := "access-token-" + strconv.Itoa(as.issued)
+		as.tokens[token] = true
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  token,
+			"token_type":    "Bearer",
+			"refresh_token": "refresh-token",
+			"expires_in":    3600,
+		})
+	})
+
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		as.mu.Lock()
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		valid := as.tokens[token]
+		as.mu.Unlock()
+
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		streamable.ServeHTTP(w, r)
+	})
+
+	as.Server = httptest.NewServer(mux)
+	return as
+}
+
+// consent follows an authorization URL like the browser of the operator, and
+// returns the state and code given to the redirect URI.
+func consent(authURL string) (string, string, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		return "", "", err
+	}
+
+	q := location.Query()
+	return q.Get("state"), q.Get("code"), nil
+}
+
+func TestOAuthAuthorization(t *testing.T) {
+	assert := assert.New(t)
+
+	as := newAuthServer()
+	defer as.Close()
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "oauth")
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"remote": {
+				Transport: TransportTypeStreamableHTTP,
+				URL:       as.URL + "/mcp",
+				OAuth:     &OAuthConfig{},
+			},
+		},
+	}
+
+	assert.NoError(cfg.Validate())
+
+	svc, err := NewService(ctx, cfg, nil,
+		WithOAuth(dir, "http://localhost:8080/api/mcp/oauth/callback"),
+	)
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	// The server waits for the authorization
+	_, err = svc.ListTools(ctx)
+	assert.ErrorIs(err, ErrNoToolsFound)
+
+	err = svc.RegisterMCPServer(ctx, "remote-2", cfg.MCPServers["remote"], true)
+	assert.ErrorIs(err, ErrAuthorizationRequired)
+
+	_, err = svc.AuthorizeMCPServer(ctx, "unknown")
+	assert.ErrorIs(err, ErrServerNotFound)
+
+	authURL, err := svc.AuthorizeMCPServer(ctx, "remote")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	state, code, err := consent(authURL)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	// A forged state is refused
+	_, err = svc.CompleteAuthorization(ctx, "forged", code)
+	assert.ErrorIs(err, ErrAuthorizationNotFound)
+
+	id, err := svc.CompleteAuthorization(ctx, state, code)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("remote", id)
+
+	// An authorization completes once
+	_, err = svc.CompleteAuthorization(ctx, state, code)
+	assert.ErrorIs(err, ErrAuthorizationNotFound)
+
+	tools, err := svc.ListTools(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(tools, 1) {
+		assert.Equal("echo", tools[0].Name)
+	}
+
+	// The client and the token are stored for the owner only
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || !assert.Len(files, 1) {
+		return
+	}
+
+	info, err := os.Stat(files[0])
+	if assert.NoError(err) {
+		assert.Equal(os.FileMode(0600), info.Mode().Perm())
+	}
+
+	store, err := newOAuthStore(dir, as.URL+"/mcp")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	clientID, _ := store.client()
+	assert.Equal("registered-client", clientID)
+
+	// An expired token is refreshed on the next request
+	s := svc.(*service)
+	shared, _ := s.oauthStore(as.URL + "/mcp")
+
+	token, _ := shared.GetToken()
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.NoError(shared.SaveToken(token))
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "echo"
+	req.Params.Arguments = map[string]any{"message": "hello"}
+
+	_, err = svc.Forward(ctx, req)
+	assert.NoError(err)
+	assert.Equal(1, as.refreshes)
+
+	token, _ = shared.GetToken()
+	assert.Equal("access-token-2", token.AccessToken)
+
+	// Another service connects with the stored token
+	svc2, err := NewService(ctx, cfg, nil,
+		WithOAuth(dir, "http://localhost:8080/api/mcp/oauth/callback"),
+	)
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc2.Close()
+
+	tools, err = svc2.ListTools(ctx)
+	assert.NoError(err)
+	assert.Len(tools, 1)
+}
+
+func TestOAuthStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	store, err := newOAuthStore(dir, "https://example.com/mcp")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = store.GetToken()
+	assert.ErrorIs(err, ErrAuthorizationRequired)
+
+	assert.NoError(store.saveClient("client", "secret"))
+	assert.NoError(store.SaveToken(&transport.Token{AccessToken: "token"}))
+
+	// Another server URL does not share the token
+	other, err := newOAuthStore(dir, "https://example.org/mcp")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = other.GetToken()
+	assert.ErrorIs(err, ErrAuthorizationRequired)
+
+	reloaded, err := newOAuthStore(dir, "https://example.com/mcp")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	token, err := reloaded.GetToken()
+	if assert.NoError(err) {
+		assert.Equal("token", token.AccessToken)
+	}
+
+	id, secret := reloaded.client()
+	assert.Equal("client", id)
+	assert.Equal("secret", secret)
+
+	// Without a directory, the store is kept in memory
+	memory, err := newOAuthStore("", "https://example.com/mcp")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	_, err = memory.GetToken()
+	assert.ErrorIs(err, ErrAuthorizationRequired)
+
+	assert.NoError(memory.SaveToken(&transport.Token{AccessToken: "token"}))
+}
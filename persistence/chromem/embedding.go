@@ -0,0 +1,173 @@
+package chromem
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/philippgille/chromem-go"
+
+	"github.com/flarexio/mcpblade/vector"
+)
+
+const (
+	DefaultOpenAIModel    = string(chromem.EmbeddingModelOpenAI3Small)
+	DefaultOllamaModel    = "nomic-embed-text"
+	DefaultHashDimensions = 512
+)
+
+var ErrUnsupportedEmbeddingProvider = errors.New("unsupported embedding provider")
+
+// NewEmbeddingFunc returns the embedding function for the configured provider.
+// OpenAI is used when no provider is set.
+func NewEmbeddingFunc(cfg vector.EmbeddingConfig) (chromem.EmbeddingFunc, error) {
+	switch cfg.Provider {
+	case "", vector.EmbeddingProviderOpenAI:
+		apiKey := cfg.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = chromem.BaseURLOpenAI
+		}
+
+		normalized := true
+		return chromem.NewEmbeddingFuncOpenAICompat(baseURL, apiKey, model, &normalized), nil
+
+	case vector.EmbeddingProviderOpenAICompat:
+		if cfg.BaseURL == "" || cfg.Model == "" {
+			return nil, errors.New("baseURL and model are required for openai-compatible embeddings")
+		}
+
+		return chromem.NewEmbeddingFuncOpenAICompat(cfg.BaseURL, cfg.APIKey, cfg.Model, nil), nil
+
+	case vector.EmbeddingProviderOllama:
+		model := cfg.Model
+		if model == "" {
+			model = DefaultOllamaModel
+		}
+
+		return chromem.NewEmbeddingFuncOllama(model, cfg.BaseURL), nil
+
+	case vector.EmbeddingProviderHash:
+		return NewHashEmbeddingFunc(cfg.Dimensions), nil
+
+	default:
+		return nil, ErrUnsupportedEmbeddingProvider
+	}
+}
+
+// NewHashEmbeddingFunc returns an offline embedding function based on a
+// hashed bag of words. Words and their character trigrams are hashed into a
+// fixed number of dimensions, so texts sharing vocabulary end up close to
+// each other without any model or network access.
+func NewHashEmbeddingFunc(dimensions int) chromem.EmbeddingFunc {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embedding := make([]float32, dimensions)
+
+		for _, word := range tokenize(text) {
+			addFeature(embedding, word, 1)
+
+			padded := "^" + word + "$"
+			if len(padded) <= 3 {
+				continue
+			}
+
+			for i := 0; i+3 <= len(padded); i++ {
+				addFeature(embedding, padded[i:i+3], 0.5)
+			}
+		}
+
+		return normalize(embedding), nil
+	}
+}
+
+// tokenize splits text into lowercase words, also splitting snake_case,
+// kebab-case and camelCase identifiers such as tool names.
+func tokenize(text string) []string {
+	var (
+		words []string
+		word  []rune
+		prev  rune
+	)
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			if unicode.IsLower(prev) {
+				flush()
+			}
+
+			word = append(word, unicode.ToLower(r))
+
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+
+		default:
+			flush()
+		}
+
+		prev = r
+	}
+
+	flush()
+
+	return words
+}
+
+func addFeature(embedding []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(feature)))
+	sum := h.Sum64()
+
+	index := sum % uint64(len(embedding))
+
+	// Use another bit of the hash as sign to reduce collision bias
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+
+	embedding[index] += weight
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, val := range v {
+		norm += float64(val) * float64(val)
+	}
+
+	if norm == 0 {
+		// An empty text has no direction; use a fixed unit vector
+		v[0] = 1
+		return v
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / norm)
+	}
+
+	return v
+}
@@ -2,6 +2,7 @@ package chromem
 
 import (
 	"context"
+	"errors"
 
 	"github.com/philippgille/chromem-go"
 
@@ -9,6 +10,11 @@ import (
 )
 
 func NewChromemVectorDB(cfg vector.Config) (vector.VectorDB, error) {
+	embed, err := NewEmbeddingFunc(cfg.Embedding)
+	if err != nil {
+		return nil, err
+	}
+
 	var db *chromem.DB
 	if !cfg.Persistent {
 		db = chromem.NewDB()
@@ -21,16 +27,16 @@ func NewChromemVectorDB(cfg vector.Config) (vector.VectorDB, error) {
 		db = d
 	}
 
-	return &chromemVectorDB{db}, nil
+	return &chromemVectorDB{db, embed}, nil
 }
 
 type chromemVectorDB struct {
-	db *chromem.DB
+	db    *chromem.DB
+	embed chromem.EmbeddingFunc
 }
 
 func (vector *chromemVectorDB) Collection(name string) (vector.Collection, error) {
-	// TODO: need to support other embedding models
-	c, err := vector.db.GetOrCreateCollection(name, nil, nil)
+	c, err := vector.db.GetOrCreateCollection(name, nil, vector.embed)
 	if err != nil {
 		return nil, err
 	}
@@ -67,11 +73,27 @@ func (c *collection) FindDocument(ctx context.Context, id string) (vector.Docume
 	}, nil
 }
 
+func (c *collection) DeleteDocument(ctx context.Context, id string) error {
+	return c.collection.Delete(ctx, nil, nil, id)
+}
+
+func (c *collection) DeleteWhere(ctx context.Context, where map[string]string) error {
+	if len(where) == 0 {
+		return errors.New("where filter is required")
+	}
+
+	return c.collection.Delete(ctx, where, nil)
+}
+
 func (c *collection) Query(ctx context.Context, query string, k int) ([]vector.Document, error) {
 	if k > c.collection.Count() {
 		k = c.collection.Count()
 	}
 
+	if k == 0 {
+		return nil, nil
+	}
+
 	results, err := c.collection.Query(ctx, query, k, nil, nil)
 	if err != nil {
 		return nil, err
@@ -89,3 +111,9 @@ func (c *collection) Query(ctx context.Context, query string, k int) ([]vector.D
 
 	return docs, nil
 }
+
+// ListDocuments returns all documents of the collection. chromem cannot list
+// documents, so they are queried with k set to their count.
+func (c *collection) ListDocuments(ctx context.Context) ([]vector.Document, error) {
+	return c.Query(ctx, "tool", c.collection.Count())
+}
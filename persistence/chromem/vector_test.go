@@ -0,0 +1,100 @@
+package chromem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/mcpblade/vector"
+)
+
+func TestHashEmbeddingCollection(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := vector.Config{
+		Enabled:    true,
+		Persistent: false,
+		Collection: "tools",
+		Embedding: vector.EmbeddingConfig{
+			Provider: vector.EmbeddingProviderHash,
+		},
+	}
+
+	db, err := NewChromemVectorDB(cfg)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	collection, err := db.Collection(cfg.Collection)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	ctx := context.Background()
+
+	docs := []vector.Document{
+		{
+			ID:       "tool_time",
+			Content:  "get_current_time Get current time in a specific timezone",
+			Metadata: map[string]string{"server_id": "time"},
+		},
+		{
+			ID:       "tool_weather",
+			Content:  "get_forecast Get the weather forecast for a location",
+			Metadata: map[string]string{"server_id": "weather"},
+		},
+		{
+			ID:       "tool_alerts",
+			Content:  "get_alerts Get weather alerts for a US state",
+			Metadata: map[string]string{"server_id": "weather"},
+		},
+	}
+
+	for _, doc := range docs {
+		if err := collection.AddDocument(ctx, doc); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+	}
+
+	results, err := collection.Query(ctx, "what's the time?", 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(results, 1)
+	assert.Equal("tool_time", results[0].ID)
+
+	results, err = collection.Query(ctx, "weather forecast", 1)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("tool_weather", results[0].ID)
+
+	err = collection.DeleteDocument(ctx, "tool_time")
+	assert.NoError(err)
+
+	_, err = collection.FindDocument(ctx, "tool_time")
+	assert.Error(err)
+
+	err = collection.DeleteWhere(ctx, map[string]string{"server_id": "weather"})
+	assert.NoError(err)
+
+	results, err = collection.Query(ctx, "weather", 5)
+	assert.NoError(err)
+	assert.Empty(results)
+}
+
+func TestTokenize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"get", "current", "time"}, tokenize("get_current_time"))
+	assert.Equal([]string{"list", "pull", "requests"}, tokenize("listPullRequests"))
+	assert.Equal([]string{"what", "s", "the", "time"}, tokenize("What's the time?"))
+}
@@ -24,25 +24,75 @@ func (mw *proxyMiddleware) Close() error {
 }
 
 func (mw *proxyMiddleware) RegisterMCPServer(ctx context.Context, id string, config MCPServerConfig, persistent ...bool) error {
-	if len(persistent) > 0 && persistent[0] {
-		return errors.New("persistent servers are not supported")
+	req := RegisterMCPServerRequest{
+		ServerID:   id,
+		Config:     config,
+		Persistent: len(persistent) > 0 && persistent[0],
 	}
 
-	req := RegisterMCPServerRequest{
+	_, err := mw.endpoints.RegisterMCPServer(ctx, req)
+	return err
+}
+
+func (mw *proxyMiddleware) UpdateMCPServer(ctx context.Context, id string, config MCPServerConfig) error {
+	req := UpdateMCPServerRequest{
 		ServerID: id,
 		Config:   config,
 	}
 
-	_, err := mw.endpoints.RegisterMCPServer(ctx, req)
+	_, err := mw.endpoints.UpdateMCPServer(ctx, req)
 	return err
 }
 
+func (mw *proxyMiddleware) ReloadMCPServers(ctx context.Context, servers map[string]MCPServerConfig) (ServerDiff, error) {
+	return ServerDiff{}, errors.New("method not implemented")
+}
+
+func (mw *proxyMiddleware) AuthorizeMCPServer(ctx context.Context, id string) (string, error) {
+	resp, err := mw.endpoints.AuthorizeMCPServer(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, ok := resp.(string)
+	if !ok {
+		return "", errors.New("invalid response type")
+	}
+
+	return authURL, nil
+}
+
+func (mw *proxyMiddleware) CompleteAuthorization(ctx context.Context, state string, code string) (string, error) {
+	req := CompleteAuthorizationRequest{
+		State: state,
+		Code:  code,
+	}
+
+	resp, err := mw.endpoints.CompleteAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	serverID, ok := resp.(string)
+	if !ok {
+		return "", errors.New("invalid response type")
+	}
+
+	return serverID, nil
+}
+
 func (mw *proxyMiddleware) UnregisterMCPServer(ctx context.Context, id string, persistent ...bool) error {
-	if len(persistent) > 0 && persistent[0] {
-		return errors.New("persistent servers are not supported")
+	req := UnregisterMCPServerRequest{
+		ServerID:   id,
+		Persistent: len(persistent) > 0 && persistent[0],
 	}
 
-	_, err := mw.endpoints.UnregisterMCPServer(ctx, id)
+	_, err := mw.endpoints.UnregisterMCPServer(ctx, req)
+	return err
+}
+
+func (mw *proxyMiddleware) Heartbeat(ctx context.Context, id string) error {
+	_, err := mw.endpoints.Heartbeat(ctx, id)
 	return err
 }
 
@@ -97,3 +147,77 @@ func (mw *proxyMiddleware) Forward(ctx context.Context, req mcp.CallToolRequest)
 
 	return result, nil
 }
+
+func (mw *proxyMiddleware) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	resp, err := mw.endpoints.ListResources(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, ok := resp.([]mcp.Resource)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+
+	return resources, nil
+}
+
+func (mw *proxyMiddleware) ListResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplate, error) {
+	resp, err := mw.endpoints.ListResourceTemplates(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, ok := resp.([]mcp.ResourceTemplate)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+
+	return templates, nil
+}
+
+func (mw *proxyMiddleware) ReadResource(ctx context.Context, req mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	resp, err := mw.endpoints.ReadResource(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := resp.(*mcp.ReadResourceResult)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+
+	return result, nil
+}
+
+func (mw *proxyMiddleware) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	resp, err := mw.endpoints.ListPrompts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prompts, ok := resp.([]mcp.Prompt)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+
+	return prompts, nil
+}
+
+func (mw *proxyMiddleware) GetPrompt(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	resp, err := mw.endpoints.GetPrompt(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := resp.(*mcp.GetPromptResult)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+
+	return result, nil
+}
+
+// OnNotification is a no-op for the proxy: notifications are delivered by the
+// transport, e.g. natsT.SubscribeNotifications.
+func (mw *proxyMiddleware) OnNotification(handler NotificationHandler) {}
@@ -0,0 +1,278 @@
+package mcpblade
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces interpolated values in logs and errors.
+const Redacted = "***"
+
+// minSecretLength is the length from which interpolated values are redacted,
+// so that short values such as ports or flags do not garble the logs.
+const minSecretLength = 4
+
+// secrets holds the values interpolated so far, to redact them.
+var secrets = struct {
+	values map[string]struct{}
+	mu     sync.RWMutex
+}{
+	values: make(map[string]struct{}),
+}
+
+func addSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	secrets.mu.Lock()
+	secrets.values[value] = struct{}{}
+	secrets.mu.Unlock()
+}
+
+// Redact replaces the values interpolated from the environment or secret
+// files in s.
+func Redact(s string) string {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+
+	if len(secrets.values) == 0 {
+		return s
+	}
+
+	// Longer values first, in case one contains another
+	values := make([]string, 0, len(secrets.values))
+	for value := range secrets.values {
+		values = append(values, value)
+	}
+
+	slices.SortFunc(values, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+
+	return s
+}
+
+// Interpolate expands ${VAR} and ${env:VAR} from the environment,
+// ${VAR:-default} with a default for unset or empty variables, and
+// ${file:/run/secrets/x} from a file without its trailing newline. $$ stands
+// for a literal $. The variables of VS Code are supported as well, see
+// expandVSCode.
+func Interpolate(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "$$"):
+			b.WriteByte('$')
+			s = s[2:]
+
+		case strings.HasPrefix(s, "${"):
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return "", errors.New("unterminated variable: " + s)
+			}
+
+			value, err := expand(s[2:end])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(value)
+			s = s[end+1:]
+
+		default:
+			b.WriteByte('$')
+			s = s[1:]
+		}
+	}
+}
+
+func expand(expr string) (string, error) {
+	if path, ok := strings.CutPrefix(expr, "file:"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		value := strings.TrimRight(string(data), "\r\n")
+		addSecret(value)
+
+		return value, nil
+	}
+
+	if value, ok, err := expandVSCode(expr); ok {
+		return value, err
+	}
+
+	name, def, hasDefault := strings.Cut(expr, ":-")
+	name = strings.TrimPrefix(name, "env:")
+
+	if name == "" {
+		return "", errors.New("invalid variable: ${" + expr + "}")
+	}
+
+	value, ok := os.LookupEnv(name)
+	if value == "" && hasDefault {
+		return def, nil
+	}
+
+	if !ok {
+		return "", errors.New("undefined variable: " + name)
+	}
+
+	addSecret(value)
+	return value, nil
+}
+
+// expandVSCode expands the variables of a VS Code mcp.json, if expr is one.
+// ${input:id}, prompted for by VS Code, is read from the environment variable
+// named after the input, e.g. GITHUB_TOKEN for ${input:github-token}. The
+// workspace folder is the working directory.
+func expandVSCode(expr string) (string, bool, error) {
+	if id, ok := strings.CutPrefix(expr, "input:"); ok {
+		name := InputVariable(id)
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", true, errors.New("undefined input: " + id + " (set " + name + ")")
+		}
+
+		addSecret(value)
+		return value, true, nil
+	}
+
+	switch expr {
+	case "workspaceFolder", "workspaceFolderBasename":
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", true, err
+		}
+
+		if expr == "workspaceFolderBasename" {
+			dir = filepath.Base(dir)
+		}
+
+		return dir, true, nil
+
+	case "userHome":
+		home, err := os.UserHomeDir()
+		return home, true, err
+
+	case "pathSeparator":
+		return string(filepath.Separator), true, nil
+	}
+
+	return "", false, nil
+}
+
+// InputVariable returns the environment variable holding the VS Code input
+// of the given ID, upper-cased with other characters than letters and digits
+// replaced by underscores.
+func InputVariable(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, id)
+}
+
+// redactedError redacts the message of an error, which stays matchable by
+// errors.Is.
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return Redact(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &redactedError{err}
+}
+
+// RedactLogs redacts interpolated values from the messages and string or
+// error fields of a logger.
+func RedactLogs() zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactCore{core}
+	})
+}
+
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = Redact(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = Redact(f.String)
+
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok {
+				f = zap.String(f.Key, Redact(err.Error()))
+			}
+		}
+
+		redacted[i] = f
+	}
+
+	return redacted
+}
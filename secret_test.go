@@ -0,0 +1,138 @@
+package mcpblade
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInterpolate(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("MCPBLADE_TOKEN", "token-123456")
+	t.Setenv("MCPBLADE_EMPTY", "")
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("file-secret\n"), 0600); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"Bearer ${MCPBLADE_TOKEN}", "Bearer token-123456"},
+		{"${env:MCPBLADE_TOKEN}", "token-123456"},
+		{"${MCPBLADE_UNSET:-fallback}", "fallback"},
+		{"${MCPBLADE_EMPTY:-fallback}", "fallback"},
+		{"${MCPBLADE_EMPTY}", ""},
+		{"${file:" + secret + "}", "file-secret"},
+		{"$$HOME costs $5", "$HOME costs $5"},
+	}
+
+	for _, test := range tests {
+		value, err := Interpolate(test.input)
+		if err != nil {
+			assert.Fail(err.Error())
+			continue
+		}
+
+		assert.Equal(test.expected, value, test.input)
+	}
+
+	_, err := Interpolate("${MCPBLADE_UNSET}")
+	assert.ErrorContains(err, "undefined variable: MCPBLADE_UNSET")
+
+	_, err = Interpolate("${MCPBLADE_TOKEN")
+	assert.Error(err)
+
+	_, err = Interpolate("${file:" + filepath.Join(t.TempDir(), "missing") + "}")
+	assert.ErrorIs(err, os.ErrNotExist)
+
+	// Interpolated values are redacted, defaults are not
+	assert.Equal("token ***, file ***, fallback", Redact("token token-123456, file file-secret, fallback"))
+}
+
+func TestInterpolateVSCode(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("GITHUB_TOKEN", "ghp-123456")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Bearer ${input:github-token}", "Bearer ghp-123456"},
+		{"${workspaceFolder}/data", wd + "/data"},
+		{"${workspaceFolderBasename}", filepath.Base(wd)},
+		{"${userHome}", home},
+	}
+
+	for _, test := range tests {
+		value, err := Interpolate(test.input)
+		if err != nil {
+			assert.Fail(err.Error())
+			continue
+		}
+
+		assert.Equal(test.expected, value, test.input)
+	}
+
+	_, err = Interpolate("${input:api-key}")
+	assert.ErrorContains(err, "undefined input: api-key (set API_KEY)")
+
+	assert.Equal("GITHUB_TOKEN", InputVariable("github-token"))
+	assert.Equal("PERPLEXITY_KEY", InputVariable("perplexity.key"))
+}
+
+func TestRedactLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("MCPBLADE_API_KEY", "sk-redacted-key")
+
+	if _, err := Interpolate("${MCPBLADE_API_KEY}"); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	log := zap.New(core, RedactLogs()).With(
+		zap.String("url", "https://example.com/mcp?key=sk-redacted-key"),
+	)
+
+	log.Error("connect sk-redacted-key", zap.Error(errors.New("invalid key sk-redacted-key")))
+
+	entries := logs.All()
+	if !assert.Len(entries, 1) {
+		return
+	}
+
+	entry := entries[0]
+	assert.Equal("connect ***", entry.Message)
+	assert.Equal("https://example.com/mcp?key=***", entry.ContextMap()["url"])
+	assert.Equal("invalid key ***", entry.ContextMap()["error"])
+
+	err := redactError(ErrServerNotFound)
+	assert.ErrorIs(err, ErrServerNotFound)
+}
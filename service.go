@@ -3,11 +3,19 @@ package mcpblade
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"go.uber.org/zap"
 
@@ -23,9 +31,26 @@ type Service interface {
 	// RegisterMCPServer adds a new MCP server to the registry.
 	RegisterMCPServer(ctx context.Context, id string, config MCPServerConfig, persistent ...bool) error
 
+	// UpdateMCPServer replaces the configuration of a persistent MCP server.
+	UpdateMCPServer(ctx context.Context, id string, config MCPServerConfig) error
+
+	// ReloadMCPServers reconciles the persistent MCP servers with a reloaded config.
+	ReloadMCPServers(ctx context.Context, servers map[string]MCPServerConfig) (ServerDiff, error)
+
+	// AuthorizeMCPServer starts the OAuth authorization of a persistent remote
+	// MCP server, and returns the URL where the operator gives consent.
+	AuthorizeMCPServer(ctx context.Context, id string) (string, error)
+
+	// CompleteAuthorization exchanges the code of an authorization callback
+	// for a token, starts the authorized server and returns its ID.
+	CompleteAuthorization(ctx context.Context, state string, code string) (string, error)
+
 	// UnregisterMCPServer removes an MCP server from the registry.
 	UnregisterMCPServer(ctx context.Context, serverID string, persistent ...bool) error
 
+	// Heartbeat keeps a temporary MCP server alive until its TTL expires again.
+	Heartbeat(ctx context.Context, serverID string) error
+
 	// ListTools returns the aggregated tool list (deduplicated),
 	ListTools(ctx context.Context) ([]mcp.Tool, error)
 
@@ -34,12 +59,47 @@ type Service interface {
 
 	// Forward routes an MCP protocol request to an appropriate backend MCP server.
 	Forward(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+	// ListResources returns the aggregated resources with namespaced URIs.
+	ListResources(ctx context.Context) ([]mcp.Resource, error)
+
+	// ListResourceTemplates returns the aggregated resource templates with namespaced URI templates.
+	ListResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplate, error)
+
+	// ReadResource routes a resource read to the backend MCP server owning the resource.
+	ReadResource(ctx context.Context, req mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error)
+
+	// ListPrompts returns the aggregated prompts, prefixed with the server ID on duplicate names.
+	ListPrompts(ctx context.Context) ([]mcp.Prompt, error)
+
+	// GetPrompt routes a prompt request to the backend MCP server owning the prompt.
+	GetPrompt(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
+
+	// OnNotification registers a handler for notifications to be relayed to downstream clients.
+	OnNotification(handler NotificationHandler)
 }
 
 type ServiceMiddleware func(Service) Service
 
-func NewService(ctx context.Context, cfg Config, vector vector.VectorDB) (Service, error) {
-	log := zap.L().With(
+// TransportFactory creates the client transport of a backend MCP server for
+// transport types that are not built into mcp-go, such as NATS.
+type TransportFactory func(config MCPServerConfig) (transport.Interface, error)
+
+type ServiceOption func(*service)
+
+// WithTransport registers the factory used to connect backend MCP servers of
+// the given transport type.
+func WithTransport(transportType TransportType, factory TransportFactory) ServiceOption {
+	return func(svc *service) {
+		svc.transports[transportType] = factory
+	}
+}
+
+// reapInterval is how often temporary servers are checked for expiry.
+const reapInterval = 30 * time.Second
+
+func NewService(ctx context.Context, cfg Config, vector vector.VectorDB, opts ...ServiceOption) (Service, error) {
+	log := zap.L().WithOptions(RedactLogs()).With(
 		zap.String("service", "mcpblade"),
 	)
 
@@ -48,8 +108,16 @@ func NewService(ctx context.Context, cfg Config, vector vector.VectorDB) (Servic
 	svc := &service{
 		persistentInstances: make(map[string]*MCPServerInstance),
 		temporaryInstances:  make(map[string]*MCPServerInstance),
+		serverTools:         make(map[string][]mcp.Tool),
 		toolRoutes:          make(map[string]string),
 		toolsCache:          make([]mcp.Tool, 0),
+		promptRoutes:        make(map[string]string),
+		promptsCache:        make([]mcp.Prompt, 0),
+		progress:            make(map[string]progressTracker),
+		handlers:            make([]NotificationHandler, 0),
+		transports:          make(map[TransportType]TransportFactory),
+		oauthStores:         make(map[string]*oauthStore),
+		authorizations:      make(map[string]*authorization),
 
 		cfg:    cfg,
 		log:    log,
@@ -57,6 +125,10 @@ func NewService(ctx context.Context, cfg Config, vector vector.VectorDB) (Servic
 		cancel: cancel,
 	}
 
+	for _, opt := range opts {
+		opt(svc)
+	}
+
 	if vector != nil {
 		collection, err := vector.Collection(cfg.Vector.Collection)
 		if err != nil {
@@ -72,7 +144,14 @@ func NewService(ctx context.Context, cfg Config, vector vector.VectorDB) (Servic
 			zap.String("server_id", id),
 		)
 
-		err := svc.RegisterMCPServer(ctx, id, config, true)
+		// Only the configs of the file may reference variables
+		conn, err := config.Interpolate()
+		if err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		err = svc.registerMCPServer(ctx, id, config, conn, true, false)
 		if err != nil {
 			log.Error(err.Error())
 			continue
@@ -82,15 +161,26 @@ func NewService(ctx context.Context, cfg Config, vector vector.VectorDB) (Servic
 	}
 
 	svc.cacheTools(ctx)
+	svc.cachePrompts(ctx)
 
-	go svc.healthMonitor(ctx, cfg.CacheRefreshTTL)
+	interval := cfg.CacheRefreshTTL
+	if interval <= 0 {
+		interval = DefaultCacheRefreshTTL
+	}
+
+	go svc.healthMonitor(ctx, interval)
+	go svc.toolsRefresher(ctx, interval)
+	go svc.reaper(ctx, reapInterval)
 
 	return svc, nil
 }
 
 type service struct {
-	// Persistent instances and their protection
+	// Persistent instances and their protection. The configs in cfg are
+	// protected by the same mutex, as they change together at runtime.
 	persistentInstances map[string]*MCPServerInstance
+	persistentMutex     sync.RWMutex
+	saveConfig          ConfigSaver
 
 	// Temporary instances and their protection
 	temporaryInstances map[string]*MCPServerInstance
@@ -99,6 +189,39 @@ type service struct {
 	// Tools cache and routing
 	toolRoutes map[string]string
 	toolsCache []mcp.Tool
+	toolsMutex sync.RWMutex
+
+	// Tools listed per persistent server and the last indexed tool documents,
+	// keyed by routed tool name. Both are only accessed while refreshing.
+	serverTools  map[string][]mcp.Tool
+	toolDocs     map[string]vector.Document
+	refreshMutex sync.Mutex
+
+	// Progress reporters of in-flight tool calls, keyed by the progress
+	// token sent to the backend
+	progress      map[string]progressTracker
+	progressSeq   atomic.Uint64
+	progressMutex sync.Mutex
+
+	// Prompts cache and routing
+	promptRoutes map[string]string
+	promptsCache []mcp.Prompt
+	promptsMutex sync.RWMutex
+
+	// Notification handlers for downstream clients
+	handlers      []NotificationHandler
+	handlersMutex sync.RWMutex
+
+	// Factories of additional backend transports
+	transports map[TransportType]TransportFactory
+
+	// OAuth stores keyed by server URL, and pending authorizations keyed by
+	// state
+	oauthDir       string
+	redirectURI    string
+	oauthStores    map[string]*oauthStore
+	authorizations map[string]*authorization
+	oauthMutex     sync.Mutex
 
 	// Vector collection (thread-safe by itself)
 	collection vector.Collection
@@ -120,6 +243,7 @@ func (svc *service) Close() error {
 	}
 
 	// Close all persistent MCP clients
+	svc.persistentMutex.Lock()
 	for id, instance := range svc.persistentInstances {
 		log := log.With(
 			zap.String("server_id", id),
@@ -133,6 +257,7 @@ func (svc *service) Close() error {
 
 		log.Info("closed persistent MCP client")
 	}
+	svc.persistentMutex.Unlock()
 
 	// Close all temporary MCP clients
 	svc.temporaryMutex.Lock()
@@ -162,13 +287,50 @@ func (svc *service) RegisterMCPServer(ctx context.Context, id string, config MCP
 		isPersistent = persistent[0]
 	}
 
+	// Configs given at runtime are taken literally, so that a caller cannot
+	// read the environment or the files of the host through them
+	if config.HasVariables() {
+		return ErrVariablesNotAllowed
+	}
+
+	if !isPersistent {
+		return svc.registerMCPServer(ctx, id, config, config, false, false)
+	}
+
+	err := svc.registerMCPServer(ctx, id, config, config, true, true)
+	if err != nil {
+		return err
+	}
+
+	svc.refreshPersistentServer(ctx, id)
+	return nil
+}
+
+// registerMCPServer connects and registers an MCP server with the interpolated
+// config conn, and keeps config as given. A persistent server is saved to the
+// config if save is set.
+func (svc *service) registerMCPServer(ctx context.Context, id string, config MCPServerConfig, conn MCPServerConfig, isPersistent bool, save bool) error {
 	if id == "" {
 		return ErrInvalidServerID
 	}
 
+	if _, ok := svc.instance(id, isPersistent); ok {
+		return ErrServerAlreadyExists
+	}
+
+	c, err := svc.connect(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	c.OnNotification(svc.handleNotification(id, isPersistent))
+
 	var instances map[string]*MCPServerInstance
 
 	if isPersistent {
+		svc.persistentMutex.Lock()
+		defer svc.persistentMutex.Unlock()
+
 		instances = svc.persistentInstances
 	} else {
 		svc.temporaryMutex.Lock()
@@ -177,241 +339,1266 @@ func (svc *service) RegisterMCPServer(ctx context.Context, id string, config MCP
 		instances = svc.temporaryInstances
 	}
 
-	_, ok := instances[id]
-	if ok {
+	// Registered concurrently while connecting
+	if _, ok := instances[id]; ok {
+		c.Close()
 		return ErrServerAlreadyExists
 	}
 
-	var (
-		c   *client.Client
-		err error
-	)
+	if save {
+		servers := svc.servers()
+		servers[id] = config
 
-	switch config.Transport {
-	case TransportTypeStdio:
-		c, err = client.NewStdioMCPClient(
-			config.Command,
-			config.Environment,
-			config.Arguments...,
-		)
+		if err := svc.saveServers(servers); err != nil {
+			c.Close()
+			return err
+		}
+	}
 
-	case TransportTypeSSE:
-		c, err = client.NewSSEMCPClient(config.URL)
+	instance := &MCPServerInstance{
+		ID:     id,
+		Client: c,
+		Config: config,
+		conn:   conn,
+	}
 
-	case TransportTypeStreamableHTTP:
-		c, err = client.NewStreamableHttpClient(config.URL)
+	instance.Beat()
 
-	default:
-		return ErrUnsupportedTransportType
+	instances[id] = instance
+
+	return nil
+}
+
+func (svc *service) UpdateMCPServer(ctx context.Context, id string, config MCPServerConfig) error {
+	if id == "" {
+		return ErrInvalidServerID
+	}
+
+	if config.HasVariables() {
+		return ErrVariablesNotAllowed
 	}
 
+	prev, err := svc.replaceMCPServer(ctx, id, config)
 	if err != nil {
 		return err
 	}
 
-	if err := c.Start(ctx); err != nil {
-		return err
+	if prev != nil {
+		if err := prev.Client.Close(); err != nil {
+			svc.log.Warn(err.Error(),
+				zap.String("action", "update_mcp_server"),
+				zap.String("server_id", id),
+			)
+		}
 	}
 
-	req := mcp.InitializeRequest{
-		Params: mcp.InitializeParams{
-			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
-			ClientInfo: mcp.Implementation{
-				Name:    "mcpblade",
-				Version: "1.0.0",
-			},
-		},
+	svc.refreshPersistentServer(ctx, id)
+	return nil
+}
+
+// replaceMCPServer connects a persistent server with the new config, and
+// swaps it for the running instance, if any, which is returned.
+func (svc *service) replaceMCPServer(ctx context.Context, id string, config MCPServerConfig) (*MCPServerInstance, error) {
+	if !svc.configured(id) {
+		return nil, ErrServerNotFound
+	}
+
+	c, err := svc.connect(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	c.OnNotification(svc.handleNotification(id, true))
+
+	svc.persistentMutex.Lock()
+	defer svc.persistentMutex.Unlock()
+
+	// Removed concurrently while connecting
+	prev, ok := svc.persistentInstances[id]
+	if _, configured := svc.cfg.MCPServers[id]; !ok && !configured {
+		c.Close()
+		return nil, ErrServerNotFound
 	}
 
-	if _, err := c.Initialize(ctx, req); err != nil {
+	servers := svc.servers()
+	servers[id] = config
+
+	if err := svc.saveServers(servers); err != nil {
 		c.Close()
-		return err
+		return nil, err
 	}
 
 	instance := &MCPServerInstance{
 		ID:     id,
 		Client: c,
 		Config: config,
+		conn:   config,
 	}
 
 	instance.Beat()
 
-	instances[id] = instance
+	svc.persistentInstances[id] = instance
 
-	return nil
+	if prev != nil {
+		prev.retired.Store(true)
+	}
+
+	return prev, nil
 }
 
-func (svc *service) UnregisterMCPServer(ctx context.Context, serverID string, persistent ...bool) error {
-	isPersistent := false
-	if len(persistent) > 0 {
-		isPersistent = persistent[0]
-	}
+// configured reports whether a persistent server is running or configured.
+func (svc *service) configured(id string) bool {
+	svc.persistentMutex.RLock()
+	defer svc.persistentMutex.RUnlock()
 
-	if isPersistent {
-		return ErrUnsupportedPersistentServerRemoval
-	}
+	_, ok := svc.persistentInstances[id]
+	_, configured := svc.cfg.MCPServers[id]
+	return ok || configured
+}
 
-	if serverID == "" {
-		return ErrInvalidServerID
+// servers returns a copy of the persistent server configs. The caller must
+// hold persistentMutex.
+func (svc *service) servers() map[string]MCPServerConfig {
+	servers := maps.Clone(svc.cfg.MCPServers)
+	if servers == nil {
+		servers = make(map[string]MCPServerConfig)
 	}
 
-	svc.temporaryMutex.Lock()
-	defer svc.temporaryMutex.Unlock()
+	return servers
+}
 
-	instance, ok := svc.temporaryInstances[serverID]
-	if !ok {
-		return ErrServerNotFound
+// saveServers saves the persistent server configs and keeps them as the
+// current config. The caller must hold persistentMutex.
+func (svc *service) saveServers(servers map[string]MCPServerConfig) error {
+	if svc.saveConfig != nil {
+		if err := svc.saveConfig(servers); err != nil {
+			return err
+		}
 	}
 
-	delete(svc.temporaryInstances, serverID)
+	svc.cfg.MCPServers = servers
+	return nil
+}
 
-	return instance.Client.Close()
+// refreshPersistentServer updates the tools, the vector collection and the
+// prompts after a persistent server changed at runtime, and notifies
+// downstream clients to re-list the resources.
+func (svc *service) refreshPersistentServer(ctx context.Context, id string) {
+	svc.refreshServerTools(ctx, id)
+	svc.cachePrompts(ctx)
+
+	svc.notify("", mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: mcp.MethodNotificationResourcesListChanged,
+		},
+	})
 }
 
-func (svc *service) healthMonitor(ctx context.Context, interval time.Duration) {
-	log := svc.log.With(
-		zap.String("action", "health_monitor"),
-		zap.Duration("interval", interval),
-	)
+// connect creates an MCP client for the given interpolated config, starts its
+// transport and performs the MCP initialization handshake. The configs kept,
+// saved or echoed are the ones before interpolation, so that they never
+// contain the secrets, which are also redacted from the returned errors.
+// Callers connect without holding the registry lock, so that a slow server
+// does not block the registry, and check again for concurrent changes after.
+func (svc *service) connect(ctx context.Context, config MCPServerConfig) (*client.Client, error) {
+	c, err := svc.dial(ctx, config)
+	if errors.Is(err, transport.ErrOAuthAuthorizationRequired) {
+		return nil, ErrAuthorizationRequired
+	}
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	return c, redactError(err)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info("done")
-			return
+func (svc *service) dial(ctx context.Context, config MCPServerConfig) (*client.Client, error) {
+	var (
+		t   transport.Interface
+		err error
+	)
 
-		case <-ticker.C:
-			log.Info("checking MCP server health")
+	switch config.Transport {
+	case TransportTypeStdio:
+		t = transport.NewStdio(
+			config.Command,
+			config.Environment,
+			config.Arguments...,
+		)
 
-			for id, instance := range svc.persistentInstances {
-				log := log.With(
-					zap.String("server_id", id),
-					zap.String("type", "persistent"),
-				)
+	case TransportTypeSSE:
+		var httpClient *http.Client
+		httpClient, err = newHTTPClient(config)
+		if err != nil {
+			return nil, err
+		}
 
-				if err := instance.Client.Ping(ctx); err != nil {
-					log.Error(err.Error())
-					continue
-				}
+		opts := []transport.ClientOption{
+			transport.WithHTTPClient(httpClient),
+			transport.WithHeaders(config.Headers),
+		}
 
-				instance.Beat()
-				log.Info("server is alive")
+		if config.OAuth != nil {
+			var oauthConfig transport.OAuthConfig
+			oauthConfig, err = svc.oauthConfig(config)
+			if err != nil {
+				return nil, err
 			}
 
-			svc.temporaryMutex.RLock()
-			for id, instance := range svc.temporaryInstances {
-				log := log.With(
-					zap.String("server_id", id),
-					zap.String("type", "temporary"),
-				)
+			opts = append(opts, transport.WithOAuth(oauthConfig))
+		}
 
-				if err := instance.Client.Ping(ctx); err != nil {
-					log.Error(err.Error())
-					continue
-				}
+		t, err = transport.NewSSE(config.URL, opts...)
+
+	case TransportTypeStreamableHTTP:
+		var httpClient *http.Client
+		httpClient, err = newHTTPClient(config)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := []transport.StreamableHTTPCOption{
+			transport.WithHTTPBasicClient(httpClient),
+			transport.WithHTTPHeaders(config.Headers),
+		}
 
-				instance.Beat()
-				log.Info("server is alive")
+		if config.OAuth != nil {
+			var oauthConfig transport.OAuthConfig
+			oauthConfig, err = svc.oauthConfig(config)
+			if err != nil {
+				return nil, err
 			}
-			svc.temporaryMutex.RUnlock()
+
+			opts = append(opts, transport.WithHTTPOAuth(oauthConfig))
+		}
+
+		t, err = transport.NewStreamableHTTP(config.URL, opts...)
+
+	default:
+		factory, ok := svc.transports[config.Transport]
+		if !ok {
+			return nil, ErrUnsupportedTransportType
 		}
+
+		t, err = factory(config)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := client.NewClient(&cancellableTransport{t})
+
+	// The connection outlives the request registering the server, e.g. the
+	// stdio process is killed when the start context is done
+	if err := c.Start(svc.ctx); err != nil {
+		return nil, err
+	}
+
+	req := mcp.InitializeRequest{
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo: mcp.Implementation{
+				Name:    "mcpblade",
+				Version: "1.0.0",
+			},
+		},
+	}
+
+	// The timeout of a remote server bounds the initialization, whereas the
+	// tool calls that follow are bounded by the contexts of their requests
+	initCtx := ctx
+	if timeout := config.Timeout.Duration(); timeout > 0 {
+		var cancel context.CancelFunc
+		initCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
+
+	if _, err := c.Initialize(initCtx, req); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
 }
 
-func (svc *service) cacheTools(ctx context.Context) {
+// ReloadMCPServers starts the added servers, stops the removed ones and
+// restarts the ones whose connection changed, while the others keep running.
+// The servers are compared with the running instances, so servers that failed
+// to start before are tried again. The config is not saved, as it was
+// reloaded from the file.
+func (svc *service) ReloadMCPServers(ctx context.Context, servers map[string]MCPServerConfig) (ServerDiff, error) {
 	log := svc.log.With(
-		zap.String("action", "refresh_tools_cache"),
+		zap.String("action", "reload_mcp_servers"),
 	)
 
-	var (
-		routes = make(map[string]string)
-		tools  = make([]mcp.Tool, 0)
-	)
+	if servers == nil {
+		servers = make(map[string]MCPServerConfig)
+	}
 
+	for id := range servers {
+		if id == "" {
+			return ServerDiff{}, ErrInvalidServerID
+		}
+	}
+
+	svc.persistentMutex.RLock()
+	prev := make(map[string]MCPServerConfig, len(svc.persistentInstances))
 	for id, instance := range svc.persistentInstances {
+		prev[id] = instance.Config
+	}
+	svc.persistentMutex.RUnlock()
+
+	diff := DiffMCPServers(prev, servers)
+
+	connected := make(map[string]*MCPServerInstance)
+
+	for _, id := range slices.Concat(diff.Added, diff.Changed) {
 		log := log.With(
 			zap.String("server_id", id),
 		)
 
-		var cursor mcp.Cursor
-		for {
-			req := mcp.ListToolsRequest{
-				PaginatedRequest: mcp.PaginatedRequest{
-					Params: mcp.PaginatedParams{
-						Cursor: cursor,
-					},
-				},
-			}
+		config := servers[id]
 
-			results, err := instance.Client.ListTools(ctx, req)
-			if err != nil {
-				log.Error(err.Error())
-				continue
-			}
+		// A changed server keeps running with its previous config
+		conn, err := config.Interpolate()
+		if err != nil {
+			log.Error(err.Error())
+			diff.Failed = append(diff.Failed, id)
+			continue
+		}
 
-			instance.Beat()
+		c, err := svc.connect(ctx, conn)
+		if err != nil {
+			log.Error(err.Error())
+			diff.Failed = append(diff.Failed, id)
+			continue
+		}
 
-			for _, tool := range results.Tools {
-				log := log.With(
-					zap.String("tool", tool.Name),
-				)
+		c.OnNotification(svc.handleNotification(id, true))
 
-				if tool.Description != "" {
-					tool.Description = tool.Description + " (provided by " + id + ")"
-				} else {
-					tool.Description = "Provided by " + id
-				}
+		instance := &MCPServerInstance{
+			ID:     id,
+			Client: c,
+			Config: config,
+			conn:   conn,
+		}
 
-				if _, ok := routes[tool.Name]; ok {
-					log.Warn("duplicate tool name found")
+		instance.Beat()
 
-					tool.Name = id + ":" + tool.Name
-					routes[tool.Name] = id
-				} else {
-					routes[tool.Name] = id
-				}
+		connected[id] = instance
+	}
 
-				tools = append(tools, tool)
-
-				// Add tool to the vector database collection
-				if svc.collection != nil {
-					doc := ToolToDocument(tool, id)
-					existingDoc, err := svc.collection.FindDocument(ctx, doc.ID)
-					if err != nil || existingDoc.ID != doc.ID {
-						err := svc.collection.AddDocument(ctx, doc)
-						if err != nil {
-							log.Error(err.Error())
-							continue
-						}
-
-						log.Info("added tool document to vector collection")
-					}
-				}
-			}
+	svc.persistentMutex.Lock()
 
-			cursor = results.NextCursor
-			if cursor == "" {
-				break
-			}
+	// Clients replaced or removed, closed after releasing the lock
+	closing := make([]*MCPServerInstance, 0)
+
+	for _, id := range diff.Removed {
+		if instance, ok := svc.persistentInstances[id]; ok {
+			instance.retired.Store(true)
+			closing = append(closing, instance)
 		}
+
+		delete(svc.persistentInstances, id)
 	}
 
-	if len(tools) == 0 {
-		log.Error(ErrNoToolsFound.Error())
+	// The reloaded config wins over changes made while connecting
+	for id, instance := range connected {
+		if prev, ok := svc.persistentInstances[id]; ok {
+			prev.retired.Store(true)
+			closing = append(closing, prev)
+		}
+
+		svc.persistentInstances[id] = instance
 	}
 
-	svc.toolRoutes = routes
-	svc.toolsCache = tools
+	// Settings other than the connection apply to the running client. The
+	// pending restart of the previous instance is stopped, and the health
+	// monitor restarts the new one with the new settings if needed.
+	for _, id := range diff.Updated {
+		prev, ok := svc.persistentInstances[id]
+		if !ok {
+			continue
+		}
 
-	log.Info("tools cached", zap.Int("count", len(tools)))
-}
+		prev.retired.Store(true)
+
+		instance := &MCPServerInstance{
+			ID:     id,
+			Client: prev.Client,
+			Config: servers[id],
+			conn:   prev.conn,
+		}
+
+		instance.Beat()
+
+		svc.persistentInstances[id] = instance
+	}
+
+	svc.cfg.MCPServers = maps.Clone(servers)
+
+	svc.persistentMutex.Unlock()
+
+	for _, instance := range closing {
+		if err := instance.Client.Close(); err != nil {
+			log.Warn(err.Error(), zap.String("server_id", instance.ID))
+		}
+	}
+
+	slices.Sort(diff.Failed)
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		svc.cacheTools(ctx)
+		svc.cachePrompts(ctx)
+
+		svc.notify("", mcp.JSONRPCNotification{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{
+				Method: mcp.MethodNotificationResourcesListChanged,
+			},
+		})
+	}
+
+	return diff, nil
+}
+
+func (svc *service) UnregisterMCPServer(ctx context.Context, serverID string, persistent ...bool) error {
+	isPersistent := false
+	if len(persistent) > 0 {
+		isPersistent = persistent[0]
+	}
+
+	if serverID == "" {
+		return ErrInvalidServerID
+	}
+
+	if isPersistent {
+		instance, err := svc.removeMCPServer(serverID)
+		if err != nil {
+			return err
+		}
+
+		if instance != nil {
+			err = instance.Client.Close()
+		}
+
+		svc.refreshPersistentServer(ctx, serverID)
+		return err
+	}
+
+	svc.temporaryMutex.Lock()
+	instance, ok := svc.temporaryInstances[serverID]
+	delete(svc.temporaryInstances, serverID)
+	svc.temporaryMutex.Unlock()
+
+	if !ok {
+		return ErrServerNotFound
+	}
+
+	instance.retired.Store(true)
+
+	return instance.Client.Close()
+}
+
+// removeMCPServer removes a persistent server from the registry and the
+// config, and returns its instance, if it is running.
+func (svc *service) removeMCPServer(id string) (*MCPServerInstance, error) {
+	svc.persistentMutex.Lock()
+	defer svc.persistentMutex.Unlock()
+
+	instance, ok := svc.persistentInstances[id]
+	if _, configured := svc.cfg.MCPServers[id]; !ok && !configured {
+		return nil, ErrServerNotFound
+	}
+
+	servers := svc.servers()
+	delete(servers, id)
+
+	if err := svc.saveServers(servers); err != nil {
+		return nil, err
+	}
+
+	delete(svc.persistentInstances, id)
+
+	if ok {
+		instance.retired.Store(true)
+	}
+
+	return instance, nil
+}
+
+func (svc *service) Heartbeat(ctx context.Context, serverID string) error {
+	if serverID == "" {
+		return ErrInvalidServerID
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return ErrServerNotFound
+	}
+
+	instance.Beat()
+	return nil
+}
+
+// instance returns a registered persistent or temporary server.
+func (svc *service) instance(id string, persistent bool) (*MCPServerInstance, bool) {
+	if !persistent {
+		return svc.temporaryInstance(id)
+	}
+
+	svc.persistentMutex.RLock()
+	defer svc.persistentMutex.RUnlock()
+
+	instance, ok := svc.persistentInstances[id]
+	return instance, ok
+}
+
+// persistentSnapshot returns a copy of the registered persistent servers, to
+// request them without holding the lock.
+func (svc *service) persistentSnapshot() map[string]*MCPServerInstance {
+	svc.persistentMutex.RLock()
+	defer svc.persistentMutex.RUnlock()
+
+	return maps.Clone(svc.persistentInstances)
+}
+
+// temporaryInstance returns a registered temporary server. The lock is only
+// held for the lookup, and not across the requests to the server, so that a
+// long tool call stalls neither the reaper nor the other temporary servers.
+func (svc *service) temporaryInstance(id string) (*MCPServerInstance, bool) {
+	svc.temporaryMutex.RLock()
+	defer svc.temporaryMutex.RUnlock()
+
+	instance, ok := svc.temporaryInstances[id]
+	return instance, ok
+}
+
+func (svc *service) healthMonitor(ctx context.Context, interval time.Duration) {
+	log := svc.log.With(
+		zap.String("action", "health_monitor"),
+		zap.Duration("interval", interval),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("done")
+			return
+
+		case <-ticker.C:
+			log.Info("checking MCP server health")
+			svc.checkHealth(ctx, log)
+		}
+	}
+}
+
+// healthCheckTimeout bounds the ping of a server by the health monitor.
+const healthCheckTimeout = 10 * time.Second
+
+// checkHealth pings all servers concurrently, each within healthCheckTimeout,
+// and schedules the restart of the servers that do not answer. The registry
+// locks are only held to take a snapshot of the servers, so that a hung
+// server neither delays the checks of the others nor blocks the registry.
+func (svc *service) checkHealth(ctx context.Context, log *zap.Logger) {
+	svc.persistentMutex.RLock()
+	persistent := slices.Collect(maps.Values(svc.persistentInstances))
+	svc.persistentMutex.RUnlock()
+
+	svc.temporaryMutex.RLock()
+	temporary := slices.Collect(maps.Values(svc.temporaryInstances))
+	svc.temporaryMutex.RUnlock()
+
+	var wg sync.WaitGroup
+
+	for _, instance := range persistent {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			svc.checkInstance(ctx, log, instance, true)
+		}()
+	}
+
+	for _, instance := range temporary {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			svc.checkInstance(ctx, log, instance, false)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (svc *service) checkInstance(ctx context.Context, log *zap.Logger, instance *MCPServerInstance, persistent bool) {
+	serverType := "temporary"
+	if persistent {
+		serverType = "persistent"
+	}
+
+	log = log.With(
+		zap.String("server_id", instance.ID),
+		zap.String("type", serverType),
+	)
+
+	pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if err := instance.Client.Ping(pingCtx); err != nil {
+		log.Error(err.Error())
+		svc.scheduleRestart(ctx, instance, persistent)
+		return
+	}
+
+	// Temporary servers are kept alive by their clients only, so a
+	// successful ping does not count as a heartbeat.
+	if persistent {
+		instance.Beat()
+	}
+
+	instance.restarts.Store(0)
+	log.Info("server is alive")
+}
+
+// reaper closes and removes temporary servers whose heartbeat is older than
+// their TTL, e.g. when the registering client was killed without unregistering.
+func (svc *service) reaper(ctx context.Context, interval time.Duration) {
+	log := svc.log.With(
+		zap.String("action", "reaper"),
+		zap.Duration("interval", interval),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("done")
+			return
+
+		case <-ticker.C:
+			svc.reapExpired(ctx)
+		}
+	}
+}
+
+func (svc *service) reapExpired(ctx context.Context) {
+	log := svc.log.With(
+		zap.String("action", "reap_expired"),
+	)
+
+	expired := make([]*MCPServerInstance, 0)
+
+	svc.temporaryMutex.Lock()
+	for id, instance := range svc.temporaryInstances {
+		if instance.IsAlive() {
+			continue
+		}
+
+		delete(svc.temporaryInstances, id)
+		instance.retired.Store(true)
+		expired = append(expired, instance)
+	}
+	svc.temporaryMutex.Unlock()
+
+	for _, instance := range expired {
+		log := log.With(
+			zap.String("server_id", instance.ID),
+			zap.String("type", "temporary"),
+			zap.Time("last_beat", time.Unix(0, instance.heartbeat.Load())),
+		)
+
+		if err := instance.Client.Close(); err != nil {
+			log.Error(err.Error())
+		}
+
+		log.Warn("expired temporary MCP server removed")
+	}
+}
+
+// scheduleRestart starts a background restart of a failed instance when its
+// restart policy allows it. Only one restart per instance runs at a time.
+func (svc *service) scheduleRestart(ctx context.Context, instance *MCPServerInstance, persistent bool) {
+	if !instance.Config.ShouldRestart(int(instance.restarts.Load())) {
+		return
+	}
+
+	if !instance.restarting.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer instance.restarting.Store(false)
+		svc.restartMCPServer(ctx, instance, persistent)
+	}()
+}
+
+// restartMCPServer re-creates the MCP client of a failed instance with
+// exponential backoff until it succeeds, the restart budget is exhausted or
+// the instance is removed or replaced.
+func (svc *service) restartMCPServer(ctx context.Context, instance *MCPServerInstance, persistent bool) {
+	log := svc.log.With(
+		zap.String("action", "restart_mcp_server"),
+		zap.String("server_id", instance.ID),
+		zap.Bool("persistent", persistent),
+		zap.String("policy", string(instance.Config.RestartPolicy)),
+	)
+
+	for {
+		attempts := int(instance.restarts.Load())
+		if !instance.Config.ShouldRestart(attempts) {
+			log.Error("restart budget exhausted", zap.Int("attempts", attempts))
+			return
+		}
+
+		backoff := instance.Config.RestartBackoff(attempts)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		// The instance was removed or replaced while waiting
+		if instance.retired.Load() {
+			log.Info("restart cancelled")
+			return
+		}
+
+		instance.restarts.Add(1)
+
+		log := log.With(
+			zap.Int("attempt", attempts+1),
+			zap.Duration("backoff", backoff),
+		)
+
+		c, err := svc.connect(ctx, instance.conn)
+		if err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		c.OnNotification(svc.handleNotification(instance.ID, persistent))
+
+		restarted := &MCPServerInstance{
+			ID:     instance.ID,
+			Client: c,
+			Config: instance.Config,
+			conn:   instance.conn,
+		}
+
+		restarted.restarts.Store(instance.restarts.Load())
+		restarted.Beat()
+
+		if !svc.replaceInstance(instance, restarted, persistent) {
+			// The instance was unregistered while restarting
+			c.Close()
+			return
+		}
+
+		if err := instance.Client.Close(); err != nil {
+			log.Warn(err.Error())
+		}
+
+		log.Info("mcp server restarted")
+
+		if persistent {
+			svc.cacheTools(ctx)
+			svc.cachePrompts(ctx)
+		}
+
+		return
+	}
+}
+
+// replaceInstance swaps prev for next in the registry if prev is still registered.
+func (svc *service) replaceInstance(prev, next *MCPServerInstance, persistent bool) bool {
+	var instances map[string]*MCPServerInstance
+
+	if persistent {
+		svc.persistentMutex.Lock()
+		defer svc.persistentMutex.Unlock()
+
+		instances = svc.persistentInstances
+	} else {
+		svc.temporaryMutex.Lock()
+		defer svc.temporaryMutex.Unlock()
+
+		instances = svc.temporaryInstances
+	}
+
+	current, ok := instances[prev.ID]
+	if !ok || current != prev {
+		return false
+	}
+
+	instances[prev.ID] = next
+	return true
+}
+
+// toolsRefresher periodically rebuilds the tools cache so that tools added,
+// removed or changed by persistent backends are picked up without a restart.
+func (svc *service) toolsRefresher(ctx context.Context, interval time.Duration) {
+	log := svc.log.With(
+		zap.String("action", "tools_refresher"),
+		zap.Duration("interval", interval),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("done")
+			return
+
+		case <-ticker.C:
+			svc.cacheTools(ctx)
+			svc.cachePrompts(ctx)
+		}
+	}
+}
+
+func (svc *service) cacheTools(ctx context.Context) {
+	log := svc.log.With(
+		zap.String("action", "refresh_tools_cache"),
+	)
+
+	// Serialize refreshes triggered by the refresher, restarts and notifications
+	svc.refreshMutex.Lock()
+	defer svc.refreshMutex.Unlock()
+
+	serverTools := make(map[string][]mcp.Tool)
+
+	for id, instance := range svc.persistentSnapshot() {
+		log := log.With(
+			zap.String("server_id", id),
+		)
+
+		tools, err := listTools(ctx, instance)
+		if err != nil {
+			log.Error(err.Error())
+
+			// Keep the last known tools so that a transient failure does
+			// not prune the server's documents from the vector collection
+			if tools, ok := svc.serverTools[id]; ok {
+				serverTools[id] = tools
+			}
+
+			continue
+		}
+
+		serverTools[id] = tools
+	}
+
+	svc.serverTools = serverTools
+	svc.rebuildToolsCache(ctx, log)
+}
+
+// refreshServerTools re-lists the tools of a single persistent server and
+// rebuilds the aggregated cache from the per-server lists.
+func (svc *service) refreshServerTools(ctx context.Context, id string) {
+	log := svc.log.With(
+		zap.String("action", "refresh_server_tools"),
+		zap.String("server_id", id),
+	)
+
+	svc.refreshMutex.Lock()
+	defer svc.refreshMutex.Unlock()
+
+	svc.persistentMutex.RLock()
+	instance, ok := svc.persistentInstances[id]
+	svc.persistentMutex.RUnlock()
+
+	if !ok {
+		delete(svc.serverTools, id)
+	} else {
+		tools, err := listTools(ctx, instance)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		svc.serverTools[id] = tools
+	}
+
+	svc.rebuildToolsCache(ctx, log)
+}
+
+// listTools lists all tools of an instance, following pagination cursors.
+func listTools(ctx context.Context, instance *MCPServerInstance) ([]mcp.Tool, error) {
+	var (
+		cursor mcp.Cursor
+		tools  []mcp.Tool
+	)
+
+	for {
+		req := mcp.ListToolsRequest{
+			PaginatedRequest: mcp.PaginatedRequest{
+				Params: mcp.PaginatedParams{
+					Cursor: cursor,
+				},
+			},
+		}
+
+		results, err := instance.Client.ListTools(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		instance.Beat()
+
+		tools = append(tools, results.Tools...)
+
+		cursor = results.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	return tools, nil
+}
+
+// rebuildToolsCache aggregates the per-server tool lists into routes and the
+// tools cache, updates the vector collection with the difference to the
+// previous snapshot and notifies downstream clients when the list changed.
+// The caller must hold refreshMutex.
+func (svc *service) rebuildToolsCache(ctx context.Context, log *zap.Logger) {
+	var (
+		routes = make(map[string]string)
+		tools  = make([]mcp.Tool, 0)
+		docs   = make(map[string]vector.Document)
+	)
+
+	// Sort server IDs so that duplicate tool names are resolved deterministically
+	ids := make([]string, 0, len(svc.serverTools))
+	for id := range svc.serverTools {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		for _, tool := range svc.serverTools[id] {
+			log := log.With(
+				zap.String("server_id", id),
+				zap.String("tool", tool.Name),
+			)
+
+			if tool.Description != "" {
+				tool.Description = tool.Description + " (provided by " + id + ")"
+			} else {
+				tool.Description = "Provided by " + id
+			}
+
+			if _, ok := routes[tool.Name]; ok {
+				log.Warn("duplicate tool name found")
+
+				tool.Name = id + ":" + tool.Name
+				routes[tool.Name] = id
+			} else {
+				routes[tool.Name] = id
+			}
+
+			tools = append(tools, tool)
+			docs[tool.Name] = ToolToDocument(tool, id)
+		}
+	}
+
+	diff := DiffToolDocuments(svc.toolDocs, docs)
+
+	if svc.collection != nil {
+		svc.reconcileToolDocuments(ctx, log, svc.toolDocs, docs, diff)
+	}
+
+	if len(tools) == 0 {
+		log.Error(ErrNoToolsFound.Error())
+	}
+
+	svc.toolsMutex.Lock()
+	svc.toolRoutes = routes
+	svc.toolsCache = tools
+	svc.toolsMutex.Unlock()
+
+	svc.toolDocs = docs
+
+	log.Info("tools cached",
+		zap.Int("count", len(tools)),
+		zap.Int("added", len(diff.Added)),
+		zap.Int("removed", len(diff.Removed)),
+		zap.Int("changed", len(diff.Changed)),
+	)
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		svc.notify("", mcp.JSONRPCNotification{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{
+				Method: mcp.MethodNotificationToolsListChanged,
+			},
+		})
+	}
+}
+
+// reconcileToolDocuments brings the vector collection in line with the new
+// snapshot: documents of removed and changed tools and of servers that are
+// no longer present are pruned, and documents of new and changed tools added.
+// Without a previous snapshot, all documents not in the new one are pruned.
+func (svc *service) reconcileToolDocuments(ctx context.Context, log *zap.Logger, prev, next map[string]vector.Document, diff ToolDiff) {
+	// Prune documents left in a persistent collection by a previous run, e.g.
+	// of servers removed while the service was not running
+	if prev == nil {
+		svc.pruneToolDocuments(ctx, log, next)
+	}
+
+	// Prune documents of removed and changed tools
+	for _, name := range append(diff.Removed, diff.Changed...) {
+		log := log.With(
+			zap.String("tool", name),
+		)
+
+		if err := svc.collection.DeleteDocument(ctx, prev[name].ID); err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		log.Info("removed tool document from vector collection")
+	}
+
+	// Prune documents of servers that are no longer present
+	servers := make(map[string]bool)
+	for _, doc := range next {
+		servers[doc.Metadata["server_id"]] = true
+	}
+
+	pruned := make(map[string]bool)
+	for _, doc := range prev {
+		id := doc.Metadata["server_id"]
+		if servers[id] || pruned[id] {
+			continue
+		}
+
+		pruned[id] = true
+
+		log := log.With(
+			zap.String("server_id", id),
+		)
+
+		where := map[string]string{"server_id": id}
+		if err := svc.collection.DeleteWhere(ctx, where); err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		log.Info("removed server documents from vector collection")
+	}
+
+	// Add new and changed tools
+	for _, name := range append(diff.Added, diff.Changed...) {
+		log := log.With(
+			zap.String("tool", name),
+		)
+
+		doc := next[name]
+		existingDoc, err := svc.collection.FindDocument(ctx, doc.ID)
+		if err == nil && existingDoc.ID == doc.ID {
+			continue
+		}
+
+		// Prune an outdated version of the tool, e.g. indexed by a previous run
+		where := map[string]string{
+			"server_id": doc.Metadata["server_id"],
+			"tool_name": doc.Metadata["tool_name"],
+		}
+
+		if err := svc.collection.DeleteWhere(ctx, where); err != nil {
+			log.Error(err.Error())
+		}
+
+		if err := svc.collection.AddDocument(ctx, doc); err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		log.Info("added tool document to vector collection")
+	}
+}
+
+// pruneToolDocuments deletes the documents of the collection that are not in
+// the snapshot.
+func (svc *service) pruneToolDocuments(ctx context.Context, log *zap.Logger, next map[string]vector.Document) {
+	docs, err := svc.collection.ListDocuments(ctx)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	ids := make(map[string]bool, len(next))
+	for _, doc := range next {
+		ids[doc.ID] = true
+	}
+
+	for _, doc := range docs {
+		if ids[doc.ID] {
+			continue
+		}
+
+		log := log.With(
+			zap.String("server_id", doc.Metadata["server_id"]),
+			zap.String("tool", doc.Metadata["tool_name"]),
+		)
+
+		if err := svc.collection.DeleteDocument(ctx, doc.ID); err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		log.Info("removed stale tool document from vector collection")
+	}
+}
+
+func supportsPrompts(instance *MCPServerInstance) bool {
+	caps, ok := capabilities(instance)
+	return !ok || caps.Prompts != nil
+}
+
+// cachePrompts lists the prompts of all persistent servers and rebuilds the
+// prompts cache, prefixing duplicate names with the server ID like tools.
+func (svc *service) cachePrompts(ctx context.Context) {
+	log := svc.log.With(
+		zap.String("action", "refresh_prompts_cache"),
+	)
+
+	svc.refreshMutex.Lock()
+	defer svc.refreshMutex.Unlock()
+
+	var (
+		routes  = make(map[string]string)
+		prompts = make([]mcp.Prompt, 0)
+	)
+
+	instances := svc.persistentSnapshot()
+
+	for _, id := range slices.Sorted(maps.Keys(instances)) {
+		instance := instances[id]
+		if !supportsPrompts(instance) {
+			continue
+		}
+
+		log := log.With(
+			zap.String("server_id", id),
+		)
+
+		result, err := instance.Client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			log.Error(err.Error())
+			continue
+		}
+
+		instance.Beat()
+
+		for _, prompt := range result.Prompts {
+			if prompt.Description != "" {
+				prompt.Description = prompt.Description + " (provided by " + id + ")"
+			} else {
+				prompt.Description = "Provided by " + id
+			}
+
+			if _, ok := routes[prompt.Name]; ok {
+				log.Warn("duplicate prompt name found", zap.String("prompt", prompt.Name))
+
+				prompt.Name = id + ":" + prompt.Name
+			}
+
+			routes[prompt.Name] = id
+			prompts = append(prompts, prompt)
+		}
+	}
+
+	svc.promptsMutex.Lock()
+	changed := !reflect.DeepEqual(svc.promptsCache, prompts)
+	svc.promptRoutes = routes
+	svc.promptsCache = prompts
+	svc.promptsMutex.Unlock()
+
+	log.Info("prompts cached", zap.Int("count", len(prompts)))
+
+	if changed {
+		svc.notify("", mcp.JSONRPCNotification{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{
+				Method: mcp.MethodNotificationPromptsListChanged,
+			},
+		})
+	}
+}
+
+func (svc *service) OnNotification(handler NotificationHandler) {
+	svc.handlersMutex.Lock()
+	defer svc.handlersMutex.Unlock()
+
+	svc.handlers = append(svc.handlers, handler)
+}
+
+// notify relays a notification to all registered handlers. serverID is empty
+// for notifications about the aggregated (persistent) servers.
+func (svc *service) notify(serverID string, notification mcp.JSONRPCNotification) {
+	svc.handlersMutex.RLock()
+	defer svc.handlersMutex.RUnlock()
+
+	for _, handler := range svc.handlers {
+		handler(serverID, notification)
+	}
+}
+
+// handleNotification returns the handler for notifications sent by a backend
+// MCP server. It runs on the transport's read loop, so any request back to
+// the backend must be made asynchronously.
+func (svc *service) handleNotification(id string, persistent bool) func(mcp.JSONRPCNotification) {
+	return func(notification mcp.JSONRPCNotification) {
+		switch notification.Method {
+		case mcp.MethodNotificationToolsListChanged:
+			if persistent {
+				go svc.refreshServerTools(svc.ctx, id)
+				return
+			}
+
+			svc.notify(id, notification)
+
+		case MethodNotificationProgress:
+			svc.dispatchProgress(notification)
+
+		case mcp.MethodNotificationPromptsListChanged:
+			if persistent {
+				go svc.cachePrompts(svc.ctx)
+				return
+			}
+
+			svc.notify(id, notification)
+
+		case mcp.MethodNotificationResourcesListChanged:
+			// Resources are listed live, so downstream clients only need to re-list
+			if persistent {
+				svc.notify("", notification)
+				return
+			}
+
+			svc.notify(id, notification)
+		}
+	}
+}
+
+func (svc *service) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		svc.toolsMutex.RLock()
+		defer svc.toolsMutex.RUnlock()
 
-func (svc *service) ListTools(ctx context.Context) ([]mcp.Tool, error) {
-	serverID, ok := ctx.Value(ServerID).(string)
-	if !ok {
 		if len(svc.toolsCache) == 0 {
 			return nil, ErrNoToolsFound
 		}
@@ -422,10 +1609,7 @@ func (svc *service) ListTools(ctx context.Context) ([]mcp.Tool, error) {
 		return tools, nil
 	}
 
-	svc.temporaryMutex.RLock()
-	defer svc.temporaryMutex.RUnlock()
-
-	instance, ok := svc.temporaryInstances[serverID]
+	instance, ok := svc.temporaryInstance(serverID)
 	if !ok {
 		return nil, ErrServerNotFound
 	}
@@ -485,8 +1669,12 @@ func (svc *service) SearchTools(ctx context.Context, query string, k ...int) ([]
 		return nil, ErrNoToolsFound
 	}
 
-	tools := make([]mcp.Tool, len(docs))
-	for i, doc := range docs {
+	svc.toolsMutex.RLock()
+	routes := svc.toolRoutes
+	svc.toolsMutex.RUnlock()
+
+	tools := make([]mcp.Tool, 0, len(docs))
+	for _, doc := range docs {
 		toolJSON, ok := doc.Metadata["tool_json"]
 		if !ok {
 			return nil, ErrInvalidToolDocument
@@ -497,7 +1685,18 @@ func (svc *service) SearchTools(ctx context.Context, query string, k ...int) ([]
 			return nil, err
 		}
 
-		tools[i] = tool
+		// Skip documents of tools that cannot be routed, e.g. while the tools
+		// are refreshed, or stale ones that could not be pruned. Searching
+		// does not modify the collection, which is reconciled by the refresh.
+		if id, ok := routes[tool.Name]; !ok || id != doc.Metadata["server_id"] {
+			continue
+		}
+
+		tools = append(tools, tool)
+	}
+
+	if len(tools) == 0 {
+		return nil, ErrNoToolsFound
 	}
 
 	return tools, nil
@@ -506,14 +1705,26 @@ func (svc *service) SearchTools(ctx context.Context, query string, k ...int) ([]
 func (svc *service) Forward(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	toolName := req.Params.Name
 
+	if report, ok := ctx.Value(Progress).(ProgressReporter); ok {
+		var release func()
+		req, release = svc.trackProgress(req, report)
+		defer release()
+	}
+
 	serverID, ok := ctx.Value(ServerID).(string)
 	if !ok {
+		svc.toolsMutex.RLock()
 		id, ok := svc.toolRoutes[toolName]
+		svc.toolsMutex.RUnlock()
+
 		if !ok {
 			return nil, ErrToolNotFound
 		}
 
+		svc.persistentMutex.RLock()
 		instance, ok := svc.persistentInstances[id]
+		svc.persistentMutex.RUnlock()
+
 		if !ok {
 			return nil, ErrToolNotFound
 		}
@@ -532,10 +1743,7 @@ func (svc *service) Forward(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 		return result, nil
 	}
 
-	svc.temporaryMutex.RLock()
-	defer svc.temporaryMutex.RUnlock()
-
-	instance, ok := svc.temporaryInstances[serverID]
+	instance, ok := svc.temporaryInstance(serverID)
 	if !ok {
 		return nil, ErrToolNotFound
 	}
@@ -549,3 +1757,322 @@ func (svc *service) Forward(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 
 	return result, nil
 }
+
+type progressTracker struct {
+	token  mcp.ProgressToken
+	report ProgressReporter
+}
+
+// trackProgress replaces the progress token of a tool call with one unique
+// across callers, and routes the progress notifications of the backend for it
+// to report with the original token. release must be called once the call
+// has completed.
+func (svc *service) trackProgress(req mcp.CallToolRequest, report ProgressReporter) (mcp.CallToolRequest, func()) {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return req, func() {}
+	}
+
+	token := "mcpblade-" + strconv.FormatUint(svc.progressSeq.Add(1), 10)
+
+	svc.progressMutex.Lock()
+	svc.progress[token] = progressTracker{
+		token:  req.Params.Meta.ProgressToken,
+		report: report,
+	}
+	svc.progressMutex.Unlock()
+
+	meta := *req.Params.Meta
+	meta.ProgressToken = token
+	req.Params.Meta = &meta
+
+	release := func() {
+		svc.progressMutex.Lock()
+		delete(svc.progress, token)
+		svc.progressMutex.Unlock()
+	}
+
+	return req, release
+}
+
+// dispatchProgress passes a progress notification of a backend to the
+// reporter of the tool call it belongs to.
+func (svc *service) dispatchProgress(notification mcp.JSONRPCNotification) {
+	token, ok := notification.Params.AdditionalFields["progressToken"].(string)
+	if !ok {
+		return
+	}
+
+	svc.progressMutex.Lock()
+	tracker, ok := svc.progress[token]
+	svc.progressMutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	fields := maps.Clone(notification.Params.AdditionalFields)
+	fields["progressToken"] = tracker.token
+	notification.Params.AdditionalFields = fields
+
+	tracker.report(notification)
+}
+
+// capabilities reports the capabilities of a backend MCP server, if known.
+func capabilities(instance *MCPServerInstance) (mcp.ServerCapabilities, bool) {
+	c, ok := instance.Client.(interface {
+		GetServerCapabilities() mcp.ServerCapabilities
+	})
+
+	if !ok {
+		return mcp.ServerCapabilities{}, false
+	}
+
+	return c.GetServerCapabilities(), true
+}
+
+func supportsResources(instance *MCPServerInstance) bool {
+	caps, ok := capabilities(instance)
+	return !ok || caps.Resources != nil
+}
+
+func (svc *service) ListResources(ctx context.Context) ([]mcp.Resource, error) {
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		log := svc.log.With(
+			zap.String("action", "list_resources"),
+		)
+
+		instances := svc.persistentSnapshot()
+
+		resources := make([]mcp.Resource, 0)
+
+		for _, id := range slices.Sorted(maps.Keys(instances)) {
+			instance := instances[id]
+			if !supportsResources(instance) {
+				continue
+			}
+
+			result, err := instance.Client.ListResources(ctx, mcp.ListResourcesRequest{})
+			if err != nil {
+				log.Error(err.Error(), zap.String("server_id", id))
+				continue
+			}
+
+			instance.Beat()
+
+			for _, resource := range result.Resources {
+				resource.URI = NamespaceResourceURI(id, resource.URI)
+				resources = append(resources, resource)
+			}
+		}
+
+		return resources, nil
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+
+	result, err := instance.Client.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Beat()
+
+	return result.Resources, nil
+}
+
+func (svc *service) ListResourceTemplates(ctx context.Context) ([]mcp.ResourceTemplate, error) {
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		log := svc.log.With(
+			zap.String("action", "list_resource_templates"),
+		)
+
+		instances := svc.persistentSnapshot()
+
+		templates := make([]mcp.ResourceTemplate, 0)
+
+		for _, id := range slices.Sorted(maps.Keys(instances)) {
+			instance := instances[id]
+			if !supportsResources(instance) {
+				continue
+			}
+
+			result, err := instance.Client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+			if err != nil {
+				log.Error(err.Error(), zap.String("server_id", id))
+				continue
+			}
+
+			instance.Beat()
+
+			for _, template := range result.ResourceTemplates {
+				if template.URITemplate == nil || template.URITemplate.Template == nil {
+					continue
+				}
+
+				uriTemplate := NamespaceResourceURI(id, template.URITemplate.Raw())
+
+				namespaced := mcp.NewResourceTemplate(uriTemplate, template.Name)
+				if namespaced.URITemplate.Template == nil {
+					log.Warn("invalid resource template", zap.String("uri_template", uriTemplate))
+					continue
+				}
+
+				template.URITemplate = namespaced.URITemplate
+				templates = append(templates, template)
+			}
+		}
+
+		return templates, nil
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+
+	result, err := instance.Client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Beat()
+
+	return result.ResourceTemplates, nil
+}
+
+func (svc *service) ReadResource(ctx context.Context, req mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		id, uri, err := ParseResourceURI(req.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		svc.persistentMutex.RLock()
+		instance, ok := svc.persistentInstances[id]
+		svc.persistentMutex.RUnlock()
+
+		if !ok {
+			return nil, ErrResourceNotFound
+		}
+
+		req.Params.URI = uri
+
+		result, err := instance.Client.ReadResource(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		instance.Beat()
+
+		for i, contents := range result.Contents {
+			switch c := contents.(type) {
+			case mcp.TextResourceContents:
+				c.URI = NamespaceResourceURI(id, c.URI)
+				result.Contents[i] = c
+
+			case mcp.BlobResourceContents:
+				c.URI = NamespaceResourceURI(id, c.URI)
+				result.Contents[i] = c
+			}
+		}
+
+		return result, nil
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return nil, ErrResourceNotFound
+	}
+
+	result, err := instance.Client.ReadResource(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Beat()
+
+	return result, nil
+}
+
+func (svc *service) ListPrompts(ctx context.Context) ([]mcp.Prompt, error) {
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		svc.promptsMutex.RLock()
+		defer svc.promptsMutex.RUnlock()
+
+		prompts := make([]mcp.Prompt, len(svc.promptsCache))
+		copy(prompts, svc.promptsCache)
+
+		return prompts, nil
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return nil, ErrServerNotFound
+	}
+
+	result, err := instance.Client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Beat()
+
+	return result.Prompts, nil
+}
+
+func (svc *service) GetPrompt(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	promptName := req.Params.Name
+
+	serverID, ok := ctx.Value(ServerID).(string)
+	if !ok {
+		svc.promptsMutex.RLock()
+		id, ok := svc.promptRoutes[promptName]
+		svc.promptsMutex.RUnlock()
+
+		if !ok {
+			return nil, ErrPromptNotFound
+		}
+
+		svc.persistentMutex.RLock()
+		instance, ok := svc.persistentInstances[id]
+		svc.persistentMutex.RUnlock()
+
+		if !ok {
+			return nil, ErrPromptNotFound
+		}
+
+		if name, ok := strings.CutPrefix(promptName, id+":"); ok {
+			req.Params.Name = name
+		}
+
+		result, err := instance.Client.GetPrompt(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		instance.Beat()
+
+		return result, nil
+	}
+
+	instance, ok := svc.temporaryInstance(serverID)
+	if !ok {
+		return nil, ErrPromptNotFound
+	}
+
+	result, err := instance.Client.GetPrompt(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	instance.Beat()
+
+	return result, nil
+}
@@ -3,11 +3,20 @@ package mcpblade
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 
 	"github.com/flarexio/mcpblade/persistence/chromem"
 	"github.com/flarexio/mcpblade/vector"
@@ -46,6 +55,9 @@ func (suite *mcpBladeTestSuite) SetupSuite() {
 			Enabled:    true,
 			Persistent: false,
 			Collection: "tools",
+			Embedding: vector.EmbeddingConfig{
+				Provider: vector.EmbeddingProviderHash,
+			},
 		},
 	}
 
@@ -176,3 +188,1453 @@ func (suite *mcpBladeTestSuite) TearDownSuite() {
 func TestMCPBladeTestSuite(t *testing.T) {
 	suite.Run(t, new(mcpBladeTestSuite))
 }
+
+// newInProcessService creates a service whose persistent servers are
+// in-process MCP servers, so that it can be tested without any backends.
+func newInProcessService(t *testing.T, servers map[string]*server.MCPServer) *service {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	svc := &service{
+		persistentInstances: make(map[string]*MCPServerInstance),
+		temporaryInstances:  make(map[string]*MCPServerInstance),
+		serverTools:         make(map[string][]mcp.Tool),
+		toolRoutes:          make(map[string]string),
+		toolsCache:          make([]mcp.Tool, 0),
+		promptRoutes:        make(map[string]string),
+		promptsCache:        make([]mcp.Prompt, 0),
+		progress:            make(map[string]progressTracker),
+		handlers:            make([]NotificationHandler, 0),
+		transports:          make(map[TransportType]TransportFactory),
+
+		log:    zap.NewNop(),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for id, s := range servers {
+		c, err := client.NewInProcessClient(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		req := mcp.InitializeRequest{
+			Params: mcp.InitializeParams{
+				ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			},
+		}
+
+		if _, err := c.Initialize(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+
+		c.OnNotification(svc.handleNotification(id, true))
+
+		instance := &MCPServerInstance{
+			ID:     id,
+			Client: c,
+		}
+
+		instance.Beat()
+
+		svc.persistentInstances[id] = instance
+	}
+
+	t.Cleanup(func() {
+		svc.Close()
+	})
+
+	return svc
+}
+
+func newEchoTool(name string) (mcp.Tool, server.ToolHandlerFunc) {
+	tool := mcp.NewTool(name,
+		mcp.WithDescription("Echo the message back"),
+		mcp.WithString("message"),
+	)
+
+	handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(req.GetString("message", "")), nil
+	}
+
+	return tool, handler
+}
+
+func TestToolsListChanged(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0",
+		server.WithToolCapabilities(true),
+	)
+
+	backend.AddTool(newEchoTool("echo"))
+
+	svc := newInProcessService(t, map[string]*server.MCPServer{
+		"backend": backend,
+	})
+
+	svc.cacheTools(svc.ctx)
+
+	var notified atomic.Int32
+	svc.OnNotification(func(serverID string, notification mcp.JSONRPCNotification) {
+		if serverID == "" && notification.Method == mcp.MethodNotificationToolsListChanged {
+			notified.Add(1)
+		}
+	})
+
+	tools, err := svc.ListTools(svc.ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(tools, 1)
+
+	backend.AddTool(newEchoTool("echo2"))
+
+	handle := svc.handleNotification("backend", true)
+	handle(mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: mcp.MethodNotificationToolsListChanged,
+		},
+	})
+
+	assert.Eventually(func() bool {
+		return notified.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	tools, err = svc.ListTools(svc.ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(tools, 2)
+}
+
+// memoryCollection is an in-memory vector.Collection whose query returns
+// all documents, used to test indexing without an embedding model.
+type memoryCollection struct {
+	docs map[string]vector.Document
+}
+
+func (c *memoryCollection) AddDocument(ctx context.Context, doc vector.Document) error {
+	c.docs[doc.ID] = doc
+	return nil
+}
+
+func (c *memoryCollection) FindDocument(ctx context.Context, id string) (vector.Document, error) {
+	doc, ok := c.docs[id]
+	if !ok {
+		return vector.Document{}, errors.New("document not found")
+	}
+
+	return doc, nil
+}
+
+func (c *memoryCollection) DeleteDocument(ctx context.Context, id string) error {
+	delete(c.docs, id)
+	return nil
+}
+
+func (c *memoryCollection) DeleteWhere(ctx context.Context, where map[string]string) error {
+	for id, doc := range c.docs {
+		matched := true
+		for k, v := range where {
+			if doc.Metadata[k] != v {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			delete(c.docs, id)
+		}
+	}
+
+	return nil
+}
+
+func (c *memoryCollection) Query(ctx context.Context, query string, k int) ([]vector.Document, error) {
+	docs := make([]vector.Document, 0, len(c.docs))
+	for _, doc := range c.docs {
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func (c *memoryCollection) ListDocuments(ctx context.Context) ([]vector.Document, error) {
+	return slices.Collect(maps.Values(c.docs)), nil
+}
+
+func TestReconcileToolDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+	backend.AddTool(newEchoTool("removed"))
+
+	svc := newInProcessService(t, map[string]*server.MCPServer{
+		"backend": backend,
+	})
+
+	// A stale document indexed by a previous run for a tool that still exists
+	stale, _ := newEchoTool("echo")
+	stale.Description = "outdated description"
+
+	collection := &memoryCollection{
+		docs: make(map[string]vector.Document),
+	}
+
+	staleDoc := ToolToDocument(stale, "backend")
+	collection.docs[staleDoc.ID] = staleDoc
+
+	svc.collection = collection
+	svc.cacheTools(svc.ctx)
+
+	assert.Len(collection.docs, 2)
+	assert.NotContains(collection.docs, staleDoc.ID)
+
+	backend.DeleteTools("removed")
+	svc.cacheTools(svc.ctx)
+
+	assert.Len(collection.docs, 1)
+
+	tools, err := svc.SearchTools(svc.ctx, "echo")
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(tools, 1)
+	assert.Equal("echo", tools[0].Name)
+
+	// Documents of tools that cannot be routed are skipped, but kept
+	orphan, _ := newEchoTool("orphan")
+	orphanDoc := ToolToDocument(orphan, "gone")
+	collection.docs[orphanDoc.ID] = orphanDoc
+
+	tools, err = svc.SearchTools(svc.ctx, "echo")
+	if assert.NoError(err) && assert.Len(tools, 1) {
+		assert.Equal("echo", tools[0].Name)
+	}
+
+	assert.Contains(collection.docs, orphanDoc.ID)
+	delete(collection.docs, orphanDoc.ID)
+
+	// Documents of servers that are no longer present are pruned as well
+	delete(svc.persistentInstances, "backend")
+	svc.cacheTools(svc.ctx)
+
+	assert.Empty(collection.docs)
+}
+
+func TestPersistedToolDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	backends := make(map[string]*server.MCPServer)
+	for _, name := range []string{"alpha", "beta"} {
+		backend := server.NewMCPServer(name, "1.0.0")
+		backend.AddTool(newEchoTool(name))
+		backends[name] = backend
+	}
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		return transport.NewInProcessTransport(backends[config.Command]), nil
+	}
+
+	vectorCfg := vector.Config{
+		Persistent: true,
+		Path:       t.TempDir(),
+		Collection: "tools",
+		Embedding: vector.EmbeddingConfig{
+			Provider: vector.EmbeddingProviderHash,
+		},
+	}
+
+	// run starts the service with the given servers on the persisted vectors,
+	// and returns the persisted documents once it is closed
+	run := func(servers ...string) []vector.Document {
+		cfg := Config{
+			MCPServers: make(map[string]MCPServerConfig),
+			Vector:     vectorCfg,
+		}
+
+		for _, id := range servers {
+			cfg.MCPServers[id] = MCPServerConfig{
+				Transport: TransportTypeInProcess,
+				Command:   id,
+			}
+		}
+
+		db, err := chromem.NewChromemVectorDB(vectorCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		svc, err := NewService(context.Background(), cfg, db, WithTransport(TransportTypeInProcess, factory))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		svc.Close()
+
+		collection, err := db.Collection(vectorCfg.Collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		docs, err := collection.ListDocuments(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return docs
+	}
+
+	assert.Len(run("alpha", "beta"), 2)
+
+	// beta was removed while the service was not running
+	docs := run("alpha")
+	if assert.Len(docs, 1) {
+		assert.Equal("alpha", docs[0].Metadata["server_id"])
+	}
+}
+
+func TestRegisterMCPServerWithTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		return transport.NewInProcessTransport(backend), nil
+	}
+
+	ctx := context.Background()
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": {
+				Transport: TransportTypeInProcess,
+			},
+		},
+	}
+
+	svc, err := NewService(ctx, cfg, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "echo",
+			Arguments: map[string]any{
+				"message": "hello",
+			},
+		},
+	}
+
+	result, err := svc.Forward(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(result.Content, 1)
+	assert.Equal("hello", result.Content[0].(mcp.TextContent).Text)
+
+	config := MCPServerConfig{
+		Transport: TransportTypeNATS,
+	}
+
+	err = svc.RegisterMCPServer(ctx, "unsupported", config)
+	assert.ErrorIs(err, ErrUnsupportedTransportType)
+}
+
+func TestInterpolatedServerConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("MCPBLADE_BACKEND", "backend-secret")
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	// Only the transport sees the interpolated config
+	var urls []string
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		urls = append(urls, config.URL)
+		return transport.NewInProcessTransport(backend), nil
+	}
+
+	var saved map[string]MCPServerConfig
+	saver := func(servers map[string]MCPServerConfig) error {
+		saved = servers
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Configs of the file are interpolated
+	config := MCPServerConfig{
+		Transport: TransportTypeInProcess,
+		URL:       "inprocess://${MCPBLADE_BACKEND}",
+	}
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": config,
+		},
+	}
+
+	svc, err := NewService(ctx, cfg, nil,
+		WithTransport(TransportTypeInProcess, factory),
+		WithConfigSaver(saver),
+	)
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	assert.Equal([]string{"inprocess://backend-secret"}, urls)
+
+	// Configs given at runtime are rejected, so that they cannot read the
+	// environment or the files of the host
+	for _, config := range []MCPServerConfig{
+		{Transport: TransportTypeInProcess, URL: "inprocess://${MCPBLADE_BACKEND}"},
+		{Transport: TransportTypeInProcess, Headers: map[string]string{"X": "${file:/etc/passwd}"}},
+	} {
+		err = svc.RegisterMCPServer(ctx, "runtime", config, true)
+		assert.ErrorIs(err, ErrVariablesNotAllowed)
+
+		err = svc.RegisterMCPServer(ctx, "runtime", config)
+		assert.ErrorIs(err, ErrVariablesNotAllowed)
+
+		err = svc.UpdateMCPServer(ctx, "backend", config)
+		assert.ErrorIs(err, ErrVariablesNotAllowed)
+	}
+
+	assert.Len(urls, 1)
+	assert.Nil(saved)
+
+	// The config written back keeps the references
+	literal := MCPServerConfig{
+		Transport: TransportTypeInProcess,
+		URL:       "inprocess://literal",
+	}
+
+	err = svc.RegisterMCPServer(ctx, "literal", literal, true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal(config, saved["backend"])
+	assert.Equal(literal, saved["literal"])
+
+	// Reloaded configs of the file are interpolated too
+	servers := map[string]MCPServerConfig{
+		"backend": config,
+		"literal": literal,
+		"unset": {
+			Transport: TransportTypeInProcess,
+			URL:       "inprocess://${MCPBLADE_UNSET}",
+		},
+	}
+
+	diff, err := svc.ReloadMCPServers(ctx, servers)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal([]string{"unset"}, diff.Failed)
+}
+
+// memoryVectorDB serves a single memoryCollection.
+type memoryVectorDB struct {
+	collection *memoryCollection
+}
+
+func (db *memoryVectorDB) Collection(name string) (vector.Collection, error) {
+	return db.collection, nil
+}
+
+func TestPersistentServerAtRuntime(t *testing.T) {
+	assert := assert.New(t)
+
+	backends := make(map[string]*server.MCPServer)
+	for _, name := range []string{"echo", "echo2", "echo3"} {
+		backend := server.NewMCPServer(name, "1.0.0")
+		backend.AddTool(newEchoTool(name))
+
+		backends[name] = backend
+	}
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	// The URL selects the in-process backend
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		return transport.NewInProcessTransport(backends[config.URL]), nil
+	}
+
+	var saved map[string]MCPServerConfig
+	saver := func(servers map[string]MCPServerConfig) error {
+		saved = servers
+		return nil
+	}
+
+	collection := &memoryCollection{docs: make(map[string]vector.Document)}
+
+	ctx := context.Background()
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"first": {
+				Transport: TransportTypeInProcess,
+				URL:       "echo",
+			},
+		},
+	}
+
+	svc, err := NewService(ctx, cfg, &memoryVectorDB{collection},
+		WithTransport(TransportTypeInProcess, factory),
+		WithConfigSaver(saver),
+	)
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	toolNames := func() []string {
+		tools, err := svc.ListTools(ctx)
+		if err != nil {
+			assert.Fail(err.Error())
+			return nil
+		}
+
+		names := make([]string, len(tools))
+		for i, tool := range tools {
+			names[i] = tool.Name
+		}
+
+		return names
+	}
+
+	assert.ElementsMatch([]string{"echo"}, toolNames())
+	assert.Nil(saved)
+
+	// Register
+	config := MCPServerConfig{
+		Transport: TransportTypeInProcess,
+		URL:       "echo2",
+	}
+
+	err = svc.RegisterMCPServer(ctx, "second", config, true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.ElementsMatch([]string{"echo", "echo2"}, toolNames())
+	assert.Len(collection.docs, 2)
+	assert.Equal(config, saved["second"])
+	assert.Contains(saved, "first")
+
+	err = svc.RegisterMCPServer(ctx, "second", config, true)
+	assert.ErrorIs(err, ErrServerAlreadyExists)
+
+	// Update
+	config.URL = "echo3"
+
+	err = svc.UpdateMCPServer(ctx, "second", config)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.ElementsMatch([]string{"echo", "echo3"}, toolNames())
+	assert.Len(collection.docs, 2)
+	assert.Equal("echo3", saved["second"].URL)
+
+	err = svc.UpdateMCPServer(ctx, "unknown", config)
+	assert.ErrorIs(err, ErrServerNotFound)
+
+	// Unregister
+	err = svc.UnregisterMCPServer(ctx, "second", true)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.ElementsMatch([]string{"echo"}, toolNames())
+	assert.Len(collection.docs, 1)
+	assert.NotContains(saved, "second")
+	assert.Contains(saved, "first")
+
+	err = svc.UnregisterMCPServer(ctx, "second", true)
+	assert.ErrorIs(err, ErrServerNotFound)
+
+	// A failed save leaves the registry unchanged
+	svc.(*service).saveConfig = func(servers map[string]MCPServerConfig) error {
+		return errors.New("read-only")
+	}
+
+	err = svc.UnregisterMCPServer(ctx, "first", true)
+	assert.Error(err)
+	assert.ElementsMatch([]string{"echo"}, toolNames())
+}
+
+func TestReloadMCPServers(t *testing.T) {
+	assert := assert.New(t)
+
+	backends := make(map[string]*server.MCPServer)
+	for _, name := range []string{"echo", "echo2", "echo3", "echo4"} {
+		backend := server.NewMCPServer(name, "1.0.0")
+		backend.AddTool(newEchoTool(name))
+
+		backends[name] = backend
+	}
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	// The URL selects the in-process backend
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		backend, ok := backends[config.URL]
+		if !ok {
+			return nil, errors.New("backend not found")
+		}
+
+		return transport.NewInProcessTransport(backend), nil
+	}
+
+	saved := false
+	saver := func(servers map[string]MCPServerConfig) error {
+		saved = true
+		return nil
+	}
+
+	ctx := context.Background()
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"first": {
+				Transport: TransportTypeInProcess,
+				URL:       "echo",
+			},
+			"second": {
+				Transport: TransportTypeInProcess,
+				URL:       "echo2",
+			},
+			"third": {
+				Transport: TransportTypeInProcess,
+				URL:       "echo3",
+			},
+		},
+	}
+
+	svc, err := NewService(ctx, cfg, nil,
+		WithTransport(TransportTypeInProcess, factory),
+		WithConfigSaver(saver),
+	)
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	instance := func(id string) *MCPServerInstance {
+		s := svc.(*service)
+
+		s.persistentMutex.RLock()
+		defer s.persistentMutex.RUnlock()
+
+		return s.persistentInstances[id]
+	}
+
+	first := instance("first")
+	second := instance("second")
+
+	servers := map[string]MCPServerConfig{
+		"first": {
+			Transport: TransportTypeInProcess,
+			URL:       "echo",
+		},
+		"second": {
+			Transport:     TransportTypeInProcess,
+			URL:           "echo2",
+			RestartPolicy: RestartPolicyAlways,
+		},
+		"fourth": {
+			Transport: TransportTypeInProcess,
+			URL:       "echo3",
+		},
+		"fifth": {
+			Transport: TransportTypeInProcess,
+			URL:       "missing",
+		},
+	}
+
+	diff, err := svc.ReloadMCPServers(ctx, servers)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal([]string{"fifth", "fourth"}, diff.Added)
+	assert.Equal([]string{"third"}, diff.Removed)
+	assert.Equal([]string{"second"}, diff.Updated)
+	assert.Equal([]string{"first"}, diff.Unchanged)
+	assert.Equal([]string{"fifth"}, diff.Failed)
+	assert.False(saved)
+
+	// Untouched servers keep running, updated ones keep their client
+	assert.Same(first, instance("first"))
+	assert.Same(second.Client, instance("second").Client)
+	assert.Equal(RestartPolicyAlways, instance("second").Config.RestartPolicy)
+	assert.Nil(instance("third"))
+	assert.Nil(instance("fifth"))
+
+	tools, err := svc.ListTools(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Len(tools, 3)
+
+	// Changed connections are restarted, and failed servers are retried
+	servers["first"] = MCPServerConfig{
+		Transport: TransportTypeInProcess,
+		URL:       "echo4",
+	}
+
+	servers["fifth"] = MCPServerConfig{
+		Transport: TransportTypeInProcess,
+		URL:       "echo",
+	}
+
+	diff, err = svc.ReloadMCPServers(ctx, servers)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal([]string{"fifth"}, diff.Added)
+	assert.Equal([]string{"first"}, diff.Changed)
+	assert.Empty(diff.Failed)
+	assert.NotSame(first.Client, instance("first").Client)
+	assert.Equal("echo4", instance("first").Config.URL)
+}
+
+func TestResources(t *testing.T) {
+	assert := assert.New(t)
+
+	fs := server.NewMCPServer("fs", "1.0.0",
+		server.WithResourceCapabilities(false, true),
+	)
+
+	fs.AddResource(
+		mcp.NewResource("file:///notes.txt", "notes", mcp.WithMIMEType("text/plain")),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      req.Params.URI,
+					MIMEType: "text/plain",
+					Text:     "hello",
+				},
+			}, nil
+		},
+	)
+
+	fs.AddResourceTemplate(
+		mcp.NewResourceTemplate("file:///logs/{date}.log", "logs"),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:  req.Params.URI,
+					Text: "log",
+				},
+			}, nil
+		},
+	)
+
+	// Servers without the resources capability are skipped
+	tools := server.NewMCPServer("tools", "1.0.0",
+		server.WithToolCapabilities(false),
+	)
+
+	svc := newInProcessService(t, map[string]*server.MCPServer{
+		"fs":    fs,
+		"tools": tools,
+	})
+
+	ctx := context.Background()
+
+	resources, err := svc.ListResources(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(resources, 1) {
+		assert.Equal("mcpblade://fs/file:///notes.txt", resources[0].URI)
+		assert.Equal("notes", resources[0].Name)
+	}
+
+	templates, err := svc.ListResourceTemplates(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(templates, 1) {
+		assert.Equal("mcpblade://fs/file:///logs/{date}.log", templates[0].URITemplate.Raw())
+	}
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = "mcpblade://fs/file:///notes.txt"
+
+	result, err := svc.ReadResource(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(result.Contents, 1) {
+		contents, ok := result.Contents[0].(mcp.TextResourceContents)
+		if assert.True(ok) {
+			assert.Equal("mcpblade://fs/file:///notes.txt", contents.URI)
+			assert.Equal("hello", contents.Text)
+		}
+	}
+
+	req.Params.URI = "mcpblade://fs/file:///logs/2025-01-01.log"
+
+	result, err = svc.ReadResource(ctx, req)
+	if assert.NoError(err) && assert.Len(result.Contents, 1) {
+		contents, ok := result.Contents[0].(mcp.TextResourceContents)
+		if assert.True(ok) {
+			assert.Equal("mcpblade://fs/file:///logs/2025-01-01.log", contents.URI)
+		}
+	}
+
+	req.Params.URI = "file:///notes.txt"
+
+	_, err = svc.ReadResource(ctx, req)
+	assert.ErrorIs(err, ErrInvalidResourceURI)
+
+	req.Params.URI = "mcpblade://unknown/file:///notes.txt"
+
+	_, err = svc.ReadResource(ctx, req)
+	assert.ErrorIs(err, ErrResourceNotFound)
+}
+
+func newGreetPrompt(greeting string) (mcp.Prompt, server.PromptHandlerFunc) {
+	prompt := mcp.NewPrompt("greet",
+		mcp.WithPromptDescription("Greet someone"),
+		mcp.WithArgument("name", mcp.RequiredArgument()),
+	)
+
+	handler := func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult(req.Params.Name, []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(greeting+", "+req.Params.Arguments["name"])),
+		}), nil
+	}
+
+	return prompt, handler
+}
+
+func TestPrompts(t *testing.T) {
+	assert := assert.New(t)
+
+	english := server.NewMCPServer("english", "1.0.0",
+		server.WithPromptCapabilities(true),
+	)
+
+	english.AddPrompt(newGreetPrompt("Hello"))
+
+	german := server.NewMCPServer("german", "1.0.0",
+		server.WithPromptCapabilities(true),
+	)
+
+	german.AddPrompt(newGreetPrompt("Hallo"))
+
+	svc := newInProcessService(t, map[string]*server.MCPServer{
+		"english": english,
+		"german":  german,
+	})
+
+	svc.cachePrompts(svc.ctx)
+
+	ctx := context.Background()
+
+	prompts, err := svc.ListPrompts(ctx)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	if assert.Len(prompts, 2) {
+		assert.Equal("greet", prompts[0].Name)
+		assert.Equal("Greet someone (provided by english)", prompts[0].Description)
+		assert.Equal("german:greet", prompts[1].Name)
+		assert.Len(prompts[1].Arguments, 1)
+	}
+
+	for name, expected := range map[string]string{
+		"greet":        "Hello, Alice",
+		"german:greet": "Hallo, Alice",
+	} {
+		req := mcp.GetPromptRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = map[string]string{"name": "Alice"}
+
+		result, err := svc.GetPrompt(ctx, req)
+		if assert.NoError(err) && assert.Len(result.Messages, 1) {
+			content, ok := result.Messages[0].Content.(mcp.TextContent)
+			if assert.True(ok) {
+				assert.Equal(expected, content.Text)
+			}
+
+			// The backend sees the original prompt name
+			assert.Equal("greet", result.Description)
+		}
+	}
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "unknown"
+
+	_, err = svc.GetPrompt(ctx, req)
+	assert.ErrorIs(err, ErrPromptNotFound)
+}
+
+// progressTransport delivers the notifications of the backend to the client,
+// which the in-process transport does not.
+type progressTransport struct {
+	*transport.InProcessTransport
+	handler func(mcp.JSONRPCNotification)
+}
+
+func (t *progressTransport) SetNotificationHandler(handler func(mcp.JSONRPCNotification)) {
+	t.handler = handler
+}
+
+func (t *progressTransport) progress(token mcp.ProgressToken, progress float64) {
+	t.handler(mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: MethodNotificationProgress,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{
+					"progressToken": token,
+					"progress":      progress,
+					"total":         2,
+				},
+			},
+		},
+	})
+}
+
+func TestForwardProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+
+	t1 := &progressTransport{
+		InProcessTransport: transport.NewInProcessTransport(backend),
+	}
+
+	backend.AddTool(mcp.NewTool("long_running"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+			t1.progress(req.Params.Meta.ProgressToken, 1)
+			t1.progress(req.Params.Meta.ProgressToken, 2)
+		}
+
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		return t1, nil
+	}
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": {
+				Transport: TransportTypeInProcess,
+			},
+		},
+	}
+
+	svc, err := NewService(context.Background(), cfg, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	var notifications []mcp.JSONRPCNotification
+	report := func(notification mcp.JSONRPCNotification) {
+		notifications = append(notifications, notification)
+	}
+
+	ctx := context.WithValue(context.Background(), Progress, ProgressReporter(report))
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "long_running",
+			Meta: &mcp.Meta{
+				ProgressToken: "client-token",
+			},
+		},
+	}
+
+	result, err := svc.Forward(ctx, req)
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("done", result.Content[0].(mcp.TextContent).Text)
+
+	// The caller's token is restored, and the request is left untouched
+	if assert.Len(notifications, 2) {
+		for i, notification := range notifications {
+			assert.Equal(MethodNotificationProgress, notification.Method)
+			assert.Equal("client-token", notification.Params.AdditionalFields["progressToken"])
+			assert.Equal(float64(i+1), notification.Params.AdditionalFields["progress"])
+		}
+	}
+
+	assert.Equal("client-token", req.Params.Meta.ProgressToken)
+	assert.Empty(svc.(*service).progress)
+
+	// Progress without a tracked call is dropped
+	t1.progress("client-token", 1)
+	assert.Len(notifications, 2)
+}
+
+// hangingTransport never answers tool calls, and records the notifications
+// sent to the backend.
+type hangingTransport struct {
+	*transport.InProcessTransport
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (t *hangingTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == string(mcp.MethodToolsCall) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return t.InProcessTransport.SendRequest(ctx, request)
+}
+
+func (t *hangingTransport) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	t.notifications <- notification
+	return nil
+}
+
+func TestForwardCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	t1 := &hangingTransport{
+		InProcessTransport: transport.NewInProcessTransport(backend),
+		notifications:      make(chan mcp.JSONRPCNotification, 2),
+	}
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		return t1, nil
+	}
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": {
+				Transport: TransportTypeInProcess,
+			},
+		},
+	}
+
+	svc, err := NewService(context.Background(), cfg, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	// Ignore notifications/initialized
+	for len(t1.notifications) > 0 {
+		<-t1.notifications
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "echo",
+		},
+	}
+
+	_, err = svc.Forward(ctx, req)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+
+	select {
+	case notification := <-t1.notifications:
+		assert.Equal(MethodNotificationCancelled, notification.Method)
+		assert.NotNil(notification.Params.AdditionalFields["requestId"])
+		assert.Equal(context.DeadlineExceeded.Error(), notification.Params.AdditionalFields["reason"])
+
+	default:
+		assert.Fail("notifications/cancelled not sent")
+	}
+}
+
+// closeRecorder records whether the client of an instance was closed.
+type closeRecorder struct {
+	client.MCPClient
+	closed atomic.Bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+	return c.MCPClient.Close()
+}
+
+func TestReapExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	slow := &hangingTransport{
+		InProcessTransport: transport.NewInProcessTransport(backend),
+		notifications:      make(chan mcp.JSONRPCNotification, 8),
+	}
+
+	svc := newInProcessService(t, nil)
+
+	clients := make(map[string]*closeRecorder)
+	for _, id := range []string{"expired", "alive"} {
+		c := client.NewClient(slow)
+		if err := c.Start(svc.ctx); err != nil {
+			assert.Fail(err.Error())
+			return
+		}
+
+		clients[id] = &closeRecorder{MCPClient: c}
+
+		instance := &MCPServerInstance{
+			ID:     id,
+			Client: clients[id],
+			Config: MCPServerConfig{TTL: Duration(time.Minute)},
+		}
+
+		instance.Beat()
+
+		svc.temporaryInstances[id] = instance
+	}
+
+	svc.temporaryInstances["expired"].heartbeat.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	// A tool call in flight does not stall the reaper
+	ctx, cancel := context.WithCancel(context.WithValue(svc.ctx, ServerID, "alive"))
+	defer cancel()
+
+	go svc.Forward(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "echo",
+		},
+	})
+
+	time.Sleep(10 * time.Millisecond)
+
+	reaped := make(chan struct{})
+	go func() {
+		svc.reapExpired(svc.ctx)
+		close(reaped)
+	}()
+
+	select {
+	case <-reaped:
+	case <-time.After(time.Second):
+		assert.Fail("reaper stalled by a tool call")
+		return
+	}
+
+	_, err := svc.ListTools(context.WithValue(svc.ctx, ServerID, "expired"))
+	assert.ErrorIs(err, ErrServerNotFound)
+
+	assert.True(clients["expired"].closed.Load())
+	assert.False(clients["alive"].closed.Load())
+	assert.Contains(svc.temporaryInstances, "alive")
+}
+
+// deadTransport fails every ping, as a crashed backend would.
+type deadTransport struct {
+	*transport.InProcessTransport
+}
+
+func (t *deadTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == string(mcp.MethodPing) {
+		return nil, errors.New("backend crashed")
+	}
+
+	return t.InProcessTransport.SendRequest(ctx, request)
+}
+
+func TestHealthRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	var dials atomic.Int32
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		if dials.Add(1) == 1 {
+			return &deadTransport{transport.NewInProcessTransport(backend)}, nil
+		}
+
+		return transport.NewInProcessTransport(backend), nil
+	}
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": {
+				Transport:     TransportTypeInProcess,
+				RestartPolicy: RestartPolicyAlways,
+			},
+		},
+	}
+
+	s, err := NewService(context.Background(), cfg, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer s.Close()
+
+	svc := s.(*service)
+
+	prev := svc.persistentInstances["backend"]
+	recorder := &closeRecorder{MCPClient: prev.Client}
+	prev.Client = recorder
+
+	// The failed ping schedules a restart after the first backoff
+	svc.checkHealth(svc.ctx, svc.log)
+
+	deadline := time.Now().Add(DefaultRestartBackoff + 2*time.Second)
+	for time.Now().Before(deadline) {
+		if recorder.closed.Load() {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.True(recorder.closed.Load())
+	assert.Equal(int32(2), dials.Load())
+
+	svc.persistentMutex.RLock()
+	next := svc.persistentInstances["backend"]
+	svc.persistentMutex.RUnlock()
+
+	if assert.NotSame(prev, next) {
+		assert.Equal(int32(1), next.restarts.Load())
+		assert.NoError(next.Client.Ping(svc.ctx))
+	}
+
+	// A successful check resets the restart budget
+	svc.checkHealth(svc.ctx, svc.log)
+	assert.Equal(int32(0), next.restarts.Load())
+}
+
+// initializingTransport blocks the initialization until release is closed.
+type initializingTransport struct {
+	*transport.InProcessTransport
+	release <-chan struct{}
+}
+
+func (t *initializingTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == string(mcp.MethodInitialize) {
+		select {
+		case <-t.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return t.InProcessTransport.SendRequest(ctx, request)
+}
+
+func TestRegisterMCPServerConnectsWithoutLock(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	release := make(chan struct{})
+
+	// The URL selects a slow or a fast backend
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		if config.URL == "slow" {
+			return &initializingTransport{transport.NewInProcessTransport(backend), release}, nil
+		}
+
+		return transport.NewInProcessTransport(backend), nil
+	}
+
+	svc, err := NewService(context.Background(), Config{}, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer svc.Close()
+
+	ctx := context.Background()
+
+	registered := make(chan error, 1)
+	go func() {
+		registered <- svc.RegisterMCPServer(ctx, "slow", MCPServerConfig{
+			Transport: TransportTypeInProcess,
+			URL:       "slow",
+		}, true)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	// A slow server being connected blocks neither the requests nor the
+	// registration of other servers
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		svc.ListResources(ctx)
+
+		err := svc.RegisterMCPServer(ctx, "fast", MCPServerConfig{
+			Transport: TransportTypeInProcess,
+			URL:       "fast",
+		}, true)
+
+		assert.NoError(err)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail("registry blocked by a slow server")
+	}
+
+	close(release)
+
+	select {
+	case err := <-registered:
+		assert.NoError(err)
+	case <-time.After(time.Second):
+		assert.Fail("slow server not registered")
+	}
+
+	s := svc.(*service)
+	assert.Contains(s.persistentInstances, "slow")
+	assert.Contains(s.persistentInstances, "fast")
+}
+
+func TestReloadCancelsPendingRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	backend := server.NewMCPServer("backend", "1.0.0")
+	backend.AddTool(newEchoTool("echo"))
+
+	const TransportTypeInProcess TransportType = "inprocess"
+
+	var dials atomic.Int32
+	factory := func(config MCPServerConfig) (transport.Interface, error) {
+		dials.Add(1)
+		return &deadTransport{transport.NewInProcessTransport(backend)}, nil
+	}
+
+	config := MCPServerConfig{
+		Transport:     TransportTypeInProcess,
+		RestartPolicy: RestartPolicyAlways,
+	}
+
+	cfg := Config{
+		MCPServers: map[string]MCPServerConfig{
+			"backend": config,
+		},
+	}
+
+	s, err := NewService(context.Background(), cfg, nil, WithTransport(TransportTypeInProcess, factory))
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer s.Close()
+
+	svc := s.(*service)
+
+	prev := svc.persistentInstances["backend"]
+
+	// The failed ping schedules a restart of the instance
+	svc.checkHealth(svc.ctx, svc.log)
+	assert.True(prev.restarting.Load())
+
+	// which is replaced by a reload before the backoff elapsed
+	config.TTL = Duration(time.Hour)
+
+	diff, err := svc.ReloadMCPServers(svc.ctx, map[string]MCPServerConfig{
+		"backend": config,
+	})
+
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal([]string{"backend"}, diff.Updated)
+	assert.True(prev.retired.Load())
+
+	deadline := time.Now().Add(DefaultRestartBackoff + 2*time.Second)
+	for prev.restarting.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The pending restart stopped without connecting again
+	assert.False(prev.restarting.Load())
+	assert.Equal(int32(1), dials.Load())
+
+	svc.persistentMutex.RLock()
+	next := svc.persistentInstances["backend"]
+	svc.persistentMutex.RUnlock()
+
+	assert.Same(prev.Client, next.Client)
+	assert.Equal(Duration(time.Hour), next.Config.TTL)
+}
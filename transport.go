@@ -0,0 +1,51 @@
+package mcpblade
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// cancelTimeout bounds sending notifications/cancelled, as the context of the
+// cancelled request can no longer be used for it.
+const cancelTimeout = 5 * time.Second
+
+// cancellableTransport notifies the backend MCP server when the context of a
+// pending request is cancelled, which the mcp-go client does not do itself.
+type cancellableTransport struct {
+	transport.Interface
+}
+
+func (t *cancellableTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	resp, err := t.Interface.SendRequest(ctx, request)
+	if err == nil || ctx.Err() == nil {
+		return resp, err
+	}
+
+	// The initialize request must not be cancelled
+	if request.Method == string(mcp.MethodInitialize) {
+		return resp, err
+	}
+
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: MethodNotificationCancelled,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{
+					"requestId": request.ID,
+					"reason":    ctx.Err().Error(),
+				},
+			},
+		},
+	}
+
+	nctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	t.Interface.SendNotification(nctx, notification)
+
+	return resp, err
+}
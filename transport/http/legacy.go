@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+// MCPSSEHandler serves the HTTP+SSE transport of protocol version
+// 2024-11-05. The stream starts a session and announces the endpoint for
+// POSTing messages, whose responses are then sent on the stream. The session
+// ends when the stream is closed.
+func MCPSSEHandler(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions, messagesPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Create(endpoints)
+		defer sessions.Delete(session.ID)
+
+		session.attached.Store(true)
+
+		startEventStream(c)
+
+		fmt.Fprintf(c.Writer, "event: endpoint\ndata: %s?sessionId=%s\n\n", messagesPath, session.ID)
+		c.Writer.Flush()
+
+		session.standalone.serveEvents(c, 0)
+	}
+}
+
+// MCPMessageHandler handles the messages POSTed to a session of the HTTP+SSE
+// transport. Messages are accepted right away, and processed in the
+// background.
+func MCPMessageHandler(sessions *Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Query("sessionId")
+		if id == "" {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "missing session ID", nil)
+			c.JSON(http.StatusBadRequest, &resp)
+			return
+		}
+
+		session, ok := sessions.Get(id)
+		if !ok {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "session not found", nil)
+			c.JSON(http.StatusNotFound, &resp)
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil || !json.Valid(data) {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, "parse error", nil)
+			c.JSON(http.StatusBadRequest, &resp)
+			return
+		}
+
+		st := session.standalone
+
+		ctx := context.WithValue(session.ctx, mcpblade.Progress, mcpblade.ProgressReporter(func(notification mcp.JSONRPCNotification) {
+			st.Send(notification)
+		}))
+
+		go func() {
+			resp := session.dispatcher.Dispatch(ctx, data)
+			if resp != nil {
+				st.Respond(resp)
+			}
+		}()
+
+		c.Status(http.StatusAccepted)
+	}
+}
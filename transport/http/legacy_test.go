@@ -0,0 +1,57 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSETransport(t *testing.T) {
+	assert := assert.New(t)
+
+	ts, _ := newTestServer(t, newTestEndpoints(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+
+	// The stream announces the endpoint of the session
+	endpoint, err := readEvent(r)
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal("endpoint", endpoint.event)
+	assert.True(strings.HasPrefix(endpoint.data, "/messages?sessionId="))
+
+	// Messages are accepted, and responded on the stream
+	msg := post(t, ctx, ts.URL+endpoint.data, "", request(1, "initialize"), "application/json")
+	msg.Body.Close()
+	assert.Equal(http.StatusAccepted, msg.StatusCode)
+
+	result, err := readEvent(r)
+	if assert.NoError(err) {
+		assert.Equal("message", result.event)
+		assert.Contains(result.data, `"id":1`)
+		assert.Contains(result.data, `"serverInfo"`)
+	}
+
+	// Messages to unknown sessions are rejected
+	msg = post(t, ctx, ts.URL+"/messages?sessionId=unknown", "", request(2, "ping"), "application/json")
+	msg.Body.Close()
+	assert.Equal(http.StatusNotFound, msg.StatusCode)
+}
@@ -1,63 +1,225 @@
 package http
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mark3labs/mcp-go/mcp"
 
+	"github.com/flarexio/mcpblade"
+
 	mcpE "github.com/flarexio/mcpblade/mcp"
 )
 
-func MCPStreamableHandler(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint) gin.HandlerFunc {
+// MCPStreamableHandler handles the JSON-RPC messages POSTed by a client. An
+// initialize request starts a session, and any other message has to carry the
+// Mcp-Session-Id returned by it.
+func MCPStreamableHandler(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req mcpE.JSONRPCRequest
-		if err := c.ShouldBind(&req); err != nil {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
 			c.Error(err)
 			c.Abort()
 
-			resp := mcp.JSONRPCError{
-				JSONRPC: mcp.JSONRPC_VERSION,
-				ID:      req.ID,
-				Error: struct {
-					Code    int    `json:"code"`
-					Message string `json:"message"`
-					Data    any    `json:"data,omitempty"`
-				}{
-					Code:    mcp.METHOD_NOT_FOUND,
-					Message: "method not found",
-				},
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.PARSE_ERROR, err.Error(), nil)
+			c.JSON(http.StatusBadRequest, &resp)
+			return
+		}
+
+		if isInitialize(data) {
+			session := sessions.Create(endpoints)
+
+			resp := session.dispatcher.Dispatch(c.Request.Context(), data)
+			if _, ok := resp.(mcp.JSONRPCResponse); ok {
+				c.Header(SessionIDHeader, session.ID)
+			} else {
+				sessions.Delete(session.ID)
 			}
-			c.JSON(http.StatusNotFound, &resp)
+
+			writeResponse(c, resp)
 			return
 		}
 
-		endpoint, ok := endpoints[req.Method]
+		session, ok := lookupSession(c, sessions)
 		if !ok {
-			err := errors.New("endpoint not found")
-			c.Error(err)
-			c.Abort()
+			return
+		}
+
+		// Without SSE, the request ends with the connection
+		if !acceptsEventStream(c) {
+			resp := session.dispatcher.Dispatch(c.Request.Context(), data)
+			writeResponse(c, resp)
+			return
+		}
+
+		// With SSE, the request outlives the connection, so that the client
+		// can resume the response stream with Last-Event-ID. It is cancelled
+		// by notifications/cancelled or with the session.
+		st := session.newStream()
+
+		ctx := context.WithValue(session.ctx, mcpblade.Progress, mcpblade.ProgressReporter(func(notification mcp.JSONRPCNotification) {
+			st.Send(notification)
+		}))
+
+		result := make(chan any, 1)
+
+		go func() {
+			defer session.release(st)
+
+			resp := session.dispatcher.Dispatch(ctx, data)
+			if resp != nil {
+				st.Respond(resp)
+			}
+
+			result <- resp
+		}()
+
+		// The response is switched to SSE on the first notification, and
+		// written as plain JSON otherwise
+		for {
+			events, changed, closed := st.next(0)
+
+			if !closed && len(events) > 0 {
+				st.Serve(c, 0)
+				return
+			}
 
-			resp := mcp.JSONRPCError{
-				JSONRPC: mcp.JSONRPC_VERSION,
-				ID:      req.ID,
-				Error: struct {
-					Code    int    `json:"code"`
-					Message string `json:"message"`
-					Data    any    `json:"data,omitempty"`
-				}{
-					Code:    mcp.METHOD_NOT_FOUND,
-					Message: "method not found",
-				},
+			if closed {
+				resp := <-result
+
+				if len(events) > 1 {
+					st.Serve(c, 0)
+					return
+				}
+
+				writeResponse(c, resp)
+				return
 			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-changed:
+			}
+		}
+	}
+}
+
+// MCPStreamHandler serves the server-initiated SSE stream of a session, or
+// resumes a stream from the Last-Event-ID.
+func MCPStreamHandler(sessions *Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsEventStream(c) {
+			c.Status(http.StatusNotAcceptable)
+			return
+		}
+
+		session, ok := lookupSession(c, sessions)
+		if !ok {
+			return
+		}
+
+		st, after := session.standalone, session.standalone.last()
+
+		if streamID, seq, ok := parseEventID(c.GetHeader(LastEventIDHeader)); ok {
+			if resumed, ok := session.stream(streamID); ok {
+				st, after = resumed, seq
+			}
+		}
+
+		// A response stream is replayed, and served until the response
+		if st != session.standalone {
+			st.Serve(c, after)
+			return
+		}
+
+		// Only one server-initiated stream is served per session
+		if !session.attached.CompareAndSwap(false, true) {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "stream already attached", nil)
+			c.JSON(http.StatusConflict, &resp)
+			return
+		}
+
+		defer func() {
+			session.touch()
+			session.attached.Store(false)
+		}()
+
+		st.Serve(c, after)
+	}
+}
+
+// MCPDeleteSessionHandler terminates a session.
+func MCPDeleteSessionHandler(sessions *Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(SessionIDHeader)
+		if id == "" {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "missing session ID", nil)
+			c.JSON(http.StatusBadRequest, &resp)
+			return
+		}
+
+		if !sessions.Delete(id) {
+			resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "session not found", nil)
 			c.JSON(http.StatusNotFound, &resp)
 			return
 		}
 
-		ctx := c.Request.Context()
-		resp := endpoint(ctx, req)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// lookupSession returns the session of a request, and writes the error
+// response if it is missing or unknown.
+func lookupSession(c *gin.Context, sessions *Sessions) (*Session, bool) {
+	id := c.GetHeader(SessionIDHeader)
+	if id == "" {
+		resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "missing session ID", nil)
+		c.JSON(http.StatusBadRequest, &resp)
+		return nil, false
+	}
+
+	session, ok := sessions.Get(id)
+	if !ok {
+		// Clients start a new session on 404
+		resp := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, "session not found", nil)
+		c.JSON(http.StatusNotFound, &resp)
+		return nil, false
+	}
+
+	return session, true
+}
+
+// isInitialize reports whether the message is an initialize request, which
+// must not be part of a batch.
+func isInitialize(data []byte) bool {
+	var req mcpE.JSONRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return false
+	}
 
-		c.JSON(http.StatusOK, &resp)
+	return req.Method == mcp.MethodInitialize
+}
+
+// writeResponse writes a JSON-RPC response, or 202 Accepted if there is
+// nothing to respond.
+func writeResponse(c *gin.Context, resp any) {
+	if resp == nil {
+		c.Status(http.StatusAccepted)
+		return
 	}
+
+	// Messages that could not be parsed are rejected as a whole
+	if err, ok := resp.(mcp.JSONRPCError); ok {
+		switch err.Error.Code {
+		case mcp.PARSE_ERROR, mcp.INVALID_REQUEST:
+			c.JSON(http.StatusBadRequest, &resp)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, &resp)
 }
@@ -0,0 +1,259 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+// newTestEndpoints returns endpoints whose tools/call reports progress, and
+// then blocks until release is closed.
+func newTestEndpoints(release <-chan struct{}) map[mcp.MCPMethod]mcpE.MCPEndpoint {
+	result := func(req mcpE.JSONRPCRequest, result any) mcp.JSONRPCMessage {
+		return mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      req.ID,
+			Result:  result,
+		}
+	}
+
+	return map[mcp.MCPMethod]mcpE.MCPEndpoint{
+		mcp.MethodInitialize: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			return result(req, mcp.InitializeResult{
+				ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+				ServerInfo: mcp.Implementation{
+					Name:    "mcpblade",
+					Version: "1.0.0",
+				},
+			})
+		},
+		mcp.MethodPing: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			return result(req, struct{}{})
+		},
+		mcp.MethodToolsCall: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			if report, ok := ctx.Value(mcpblade.Progress).(mcpblade.ProgressReporter); ok {
+				report(mcp.JSONRPCNotification{
+					JSONRPC: mcp.JSONRPC_VERSION,
+					Notification: mcp.Notification{
+						Method: mcpblade.MethodNotificationProgress,
+					},
+				})
+			}
+
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+
+			return result(req, mcp.NewToolResultText("done"))
+		},
+	}
+}
+
+// newTestServer serves all MCP transports with the given endpoints.
+func newTestServer(t *testing.T, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint) (*httptest.Server, *Sessions) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	sessions := NewSessions()
+
+	AddStreamableRouters(r, endpoints, sessions)
+	AddSSERouters(r, endpoints, sessions)
+	AddWebSocketRouters(r, endpoints, sessions)
+
+	ts := httptest.NewServer(r)
+
+	// Sessions are closed first, which ends their streams
+	t.Cleanup(ts.Close)
+	t.Cleanup(sessions.Close)
+
+	return ts, sessions
+}
+
+func request(id int, method string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s"}`, id, method)
+}
+
+func post(t *testing.T, ctx context.Context, url string, sessionID string, body string, accept string) *http.Response {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+
+	if sessionID != "" {
+		req.Header.Set(SessionIDHeader, sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp
+}
+
+// initialize starts a Streamable HTTP session and returns its ID.
+func initialize(t *testing.T, url string) string {
+	resp := post(t, context.Background(), url, "", request(1, "initialize"), "application/json")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.Header.Get(SessionIDHeader)
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvent reads the next event of an SSE response.
+func readEvent(r *bufio.Reader) (sseEvent, error) {
+	var e sseEvent
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return e, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if e.event != "" || e.data != "" {
+				return e, nil
+			}
+
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			e.id = value
+		case "event":
+			e.event = value
+		case "data":
+			e.data = value
+		}
+	}
+}
+
+func TestStreamableHTTPSession(t *testing.T) {
+	assert := assert.New(t)
+
+	ts, _ := newTestServer(t, newTestEndpoints(nil))
+	url := ts.URL + "/mcp/"
+
+	// initialize starts a session
+	sessionID := initialize(t, url)
+	assert.NotEmpty(sessionID)
+
+	resp := post(t, context.Background(), url, sessionID, request(2, "ping"), "application/json")
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	// Requests without a session or with an unknown one are rejected
+	resp = post(t, context.Background(), url, "", request(2, "ping"), "application/json")
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(t, context.Background(), url, "unknown", request(2, "ping"), "application/json")
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// DELETE terminates the session
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set(SessionIDHeader, sessionID)
+
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusNoContent, resp.StatusCode)
+	}
+
+	resp = post(t, context.Background(), url, sessionID, request(2, "ping"), "application/json")
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(req)
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestStreamableHTTPResume(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+
+	ts, _ := newTestServer(t, newTestEndpoints(release))
+	url := ts.URL + "/mcp/"
+
+	sessionID := initialize(t, url)
+
+	// The progress notification switches the response to SSE
+	ctx, cancel := context.WithCancel(context.Background())
+
+	resp := post(t, ctx, url, sessionID, request(2, "tools/call"), "application/json, text/event-stream")
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	progress, err := readEvent(bufio.NewReader(resp.Body))
+	if !assert.NoError(err) {
+		cancel()
+		return
+	}
+
+	assert.Contains(progress.data, mcpblade.MethodNotificationProgress)
+
+	// The connection breaks before the response
+	cancel()
+	resp.Body.Close()
+
+	close(release)
+
+	// The stream is resumed after the last event received
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionIDHeader, sessionID)
+	req.Header.Set(LastEventIDHeader, progress.id)
+
+	resp, err = http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	r := bufio.NewReader(resp.Body)
+
+	replayed, err := readEvent(r)
+	if assert.NoError(err) {
+		assert.NotEqual(progress.id, replayed.id)
+		assert.Contains(replayed.data, `"id":2`)
+		assert.Contains(replayed.data, `"result"`)
+	}
+
+	// The resumed stream ends with the response
+	_, err = readEvent(r)
+	assert.Error(err)
+}
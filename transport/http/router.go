@@ -14,17 +14,40 @@ func AddRouters(r *gin.Engine, endpoints mcpblade.EndpointSet) {
 	api := r.Group("/api")
 	{
 		api.POST("/mcp/register", RegisterMCPServerHandler(endpoints.RegisterMCPServer))
+		api.PUT("/mcp/update", UpdateMCPServerHandler(endpoints.UpdateMCPServer))
 		api.DELETE("/mcp/unregister/:server_id", UnregisterMCPServerHandler(endpoints.UnregisterMCPServer))
+		api.POST("/mcp/heartbeat/:server_id", HeartbeatHandler(endpoints.Heartbeat))
+		api.POST("/mcp/oauth/authorize/:server_id", AuthorizeMCPServerHandler(endpoints.AuthorizeMCPServer))
+		api.GET("/mcp/oauth/callback", OAuthCallbackHandler(endpoints.CompleteAuthorization))
 		api.GET("/mcp/tools", ListToolsHandler(endpoints.ListTools))
 		api.GET("/mcp/tools/search", SearchToolsHandler(endpoints.SearchTools))
 		api.POST("/mcp/forward", ForwardHandler(endpoints.Forward))
+		api.GET("/mcp/resources", ListResourcesHandler(endpoints.ListResources))
+		api.GET("/mcp/resources/templates", ListResourcesHandler(endpoints.ListResourceTemplates))
+		api.POST("/mcp/resources/read", ReadResourceHandler(endpoints.ReadResource))
+		api.GET("/mcp/prompts", ListPromptsHandler(endpoints.ListPrompts))
+		api.POST("/mcp/prompts/get", GetPromptHandler(endpoints.GetPrompt))
 	}
 }
 
-func AddStreamableRouters(r *gin.Engine, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint) {
+func AddStreamableRouters(r *gin.Engine, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions) {
 	mcp := r.Group("/mcp")
 	{
-		mcp.POST("/", MCPStreamableHandler(endpoints))
-		// mcp.GET("/sse", MCPSSSEHandler(endpoints))
+		mcp.POST("/", MCPStreamableHandler(endpoints, sessions))
+		mcp.GET("/", MCPStreamHandler(sessions))
+		mcp.DELETE("/", MCPDeleteSessionHandler(sessions))
 	}
 }
+
+// AddSSERouters adds the deprecated HTTP+SSE transport for clients that do
+// not support Streamable HTTP yet.
+func AddSSERouters(r *gin.Engine, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions) {
+	r.GET("/sse", MCPSSEHandler(endpoints, sessions, "/messages"))
+	r.POST("/messages", MCPMessageHandler(sessions))
+}
+
+// AddWebSocketRouters adds the WebSocket transport for browser-based clients,
+// which may connect from the same host, localhost or the allowed origins.
+func AddWebSocketRouters(r *gin.Engine, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions, allowedOrigins ...string) {
+	r.GET("/ws", MCPWebSocketHandler(endpoints, sessions, allowedOrigins...))
+}
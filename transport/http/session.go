@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+const (
+	SessionIDHeader   = "Mcp-Session-Id"
+	LastEventIDHeader = "Last-Event-ID"
+)
+
+const (
+	// DefaultSessionTTL is how long an idle session is kept.
+	DefaultSessionTTL = 30 * time.Minute
+
+	// streamRetention is how long a completed response stream can be resumed.
+	streamRetention = time.Minute
+
+	// pruneInterval is how often expired sessions are removed, at most.
+	pruneInterval = time.Minute
+)
+
+// Session is an MCP session, started by an initialize request with Streamable
+// HTTP, or by the SSE stream with HTTP+SSE.
+type Session struct {
+	ID string
+
+	// Temporary server the session is bound to, if any
+	serverID string
+
+	dispatcher *mcpE.Dispatcher
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	// Server-initiated stream, served by GET
+	standalone *stream
+	attached   atomic.Bool
+
+	// Response streams of POST requests
+	streams   map[int64]*stream
+	streamSeq int64
+	mu        sync.Mutex
+
+	lastSeen atomic.Int64
+}
+
+// Notify sends a notification on the server-initiated stream.
+func (s *Session) Notify(notification mcp.JSONRPCNotification) {
+	s.standalone.Send(notification)
+}
+
+func (s *Session) touch() {
+	s.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (s *Session) expired(ttl time.Duration) bool {
+	if s.attached.Load() {
+		return false
+	}
+
+	lastSeen := time.Unix(0, s.lastSeen.Load())
+	return time.Since(lastSeen) > ttl
+}
+
+// newStream starts the response stream of a request.
+func (s *Session) newStream() *stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.streamSeq++
+
+	st := newStream(s.streamSeq)
+	s.streams[st.id] = st
+
+	return st
+}
+
+// release closes a response stream, which can still be resumed for a while.
+func (s *Session) release(st *stream) {
+	st.Close()
+
+	time.AfterFunc(streamRetention, func() {
+		s.mu.Lock()
+		delete(s.streams, st.id)
+		s.mu.Unlock()
+	})
+}
+
+// stream returns the stream with the given ID, where 0 is the
+// server-initiated stream.
+func (s *Session) stream(id int64) (*stream, bool) {
+	if id == s.standalone.id {
+		return s.standalone, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[id]
+	return st, ok
+}
+
+func (s *Session) close() {
+	s.cancel()
+	s.standalone.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.streams {
+		st.Close()
+	}
+}
+
+// Sessions holds the MCP sessions of the HTTP transports.
+type Sessions struct {
+	sessions map[string]*Session
+	ttl      time.Duration
+	mu       sync.RWMutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSessions returns the session store, where idle sessions expire after
+// the given TTL, or DefaultSessionTTL if not set. Expired sessions are
+// removed in the background until the store is closed.
+func NewSessions(ttl ...time.Duration) *Sessions {
+	sessionTTL := DefaultSessionTTL
+	if len(ttl) > 0 && ttl[0] > 0 {
+		sessionTTL = ttl[0]
+	}
+
+	s := &Sessions{
+		sessions: make(map[string]*Session),
+		ttl:      sessionTTL,
+		done:     make(chan struct{}),
+	}
+
+	go s.pruner(min(sessionTTL, pruneInterval))
+
+	return s
+}
+
+// pruner periodically removes the expired sessions, so that the sessions
+// abandoned by their clients are released without waiting for a new one.
+func (s *Sessions) pruner(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-ticker.C:
+			s.prune()
+		}
+	}
+}
+
+// prune closes and removes the expired sessions.
+func (s *Sessions) prune() {
+	expired := make([]*Session, 0)
+
+	s.mu.Lock()
+	for id, session := range s.sessions {
+		if session.expired(s.ttl) {
+			expired = append(expired, session)
+			delete(s.sessions, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, session := range expired {
+		session.close()
+	}
+}
+
+// Close stops pruning and terminates all sessions.
+func (s *Sessions) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*Session)
+	s.mu.Unlock()
+
+	for _, session := range sessions {
+		session.close()
+	}
+}
+
+// Create starts a session dispatching to the given endpoints, optionally
+// bound to a temporary server.
+func (s *Sessions) Create(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, serverID ...string) *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var id string
+	if len(serverID) > 0 && serverID[0] != "" {
+		id = serverID[0]
+		ctx = context.WithValue(ctx, mcpblade.ServerID, id)
+	}
+
+	session := &Session{
+		ID:         newSessionID(),
+		serverID:   id,
+		dispatcher: mcpE.NewDispatcher(endpoints, mcpE.NewInflightRequests()),
+		ctx:        ctx,
+		cancel:     cancel,
+		standalone: newStream(0),
+		streams:    make(map[int64]*stream),
+	}
+
+	session.touch()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = session
+	return session
+}
+
+// Get returns an active session, and marks it as used.
+func (s *Sessions) Get(id string) (*Session, bool) {
+	s.mu.RLock()
+	session, ok := s.sessions[id]
+	s.mu.RUnlock()
+
+	if !ok || session.expired(s.ttl) {
+		return nil, false
+	}
+
+	session.touch()
+	return session, true
+}
+
+// Delete terminates a session, and cancels its in-flight requests.
+func (s *Sessions) Delete(id string) bool {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	session.close()
+	return true
+}
+
+// Broadcast sends a notification to the sessions bound to the given server,
+// where an empty server ID stands for the aggregated servers. It can be
+// registered with Service.OnNotification.
+func (s *Sessions) Broadcast(serverID string, notification mcp.JSONRPCNotification) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.serverID == serverID {
+			session.Notify(notification)
+		}
+	}
+}
+
+func newSessionID() string {
+	bs := make([]byte, 16)
+	rand.Read(bs)
+
+	return hex.EncodeToString(bs)
+}
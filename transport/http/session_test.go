@@ -0,0 +1,41 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+func TestSessionsPrune(t *testing.T) {
+	assert := assert.New(t)
+
+	sessions := NewSessions(20 * time.Millisecond)
+	defer sessions.Close()
+
+	endpoints := make(map[mcp.MCPMethod]mcpE.MCPEndpoint)
+
+	idle := sessions.Create(endpoints)
+
+	attached := sessions.Create(endpoints)
+	attached.attached.Store(true)
+
+	// Idle sessions are removed without waiting for a new session
+	assert.Eventually(func() bool {
+		sessions.mu.RLock()
+		defer sessions.mu.RUnlock()
+
+		_, ok := sessions.sessions[idle.ID]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Error(idle.ctx.Err())
+
+	// Sessions with an attached stream are kept
+	_, ok := sessions.Get(attached.ID)
+	assert.True(ok)
+	assert.NoError(attached.ctx.Err())
+}
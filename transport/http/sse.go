@@ -0,0 +1,222 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStreamEvents is the number of events kept per stream for resumption.
+// Responses not delivered yet are kept beyond it.
+const maxStreamEvents = 100
+
+// acceptsEventStream reports whether the client accepts an SSE response.
+func acceptsEventStream(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+type event struct {
+	id       string
+	seq      int64
+	data     []byte
+	response bool
+}
+
+// stream is a sequence of SSE events of a session, i.e. the response of a
+// POST request or the server-initiated GET stream. Recent events are kept, so
+// that a client can resume a broken connection with Last-Event-ID.
+// Notifications may be dropped for a slow client, whereas responses are kept
+// until they are delivered, as the client would wait for them forever.
+type stream struct {
+	id        int64
+	seq       int64
+	delivered int64
+	events    []event
+	closed    bool
+	changed   chan struct{}
+	mu        sync.Mutex
+}
+
+func newStream(id int64) *stream {
+	return &stream{
+		id:      id,
+		changed: make(chan struct{}),
+	}
+}
+
+// Send appends a JSON-RPC notification to the stream.
+func (s *stream) Send(msg any) {
+	s.send(msg, false)
+}
+
+// Respond appends a JSON-RPC response to the stream, which is kept until it is
+// delivered.
+func (s *stream) Respond(msg any) {
+	s.send(msg, true)
+}
+
+func (s *stream) send(msg any, response bool) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.seq++
+	s.events = append(s.events, event{
+		id:       eventID(s.id, s.seq),
+		seq:      s.seq,
+		data:     data,
+		response: response,
+	})
+
+	s.trim()
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// trim drops the events beyond maxStreamEvents, except for the responses not
+// delivered yet. The caller must hold mu.
+func (s *stream) trim() {
+	if len(s.events) <= maxStreamEvents {
+		return
+	}
+
+	cut := len(s.events) - maxStreamEvents
+
+	events := make([]event, 0, maxStreamEvents)
+	for _, e := range s.events[:cut] {
+		if e.response && e.seq > s.delivered {
+			events = append(events, e)
+		}
+	}
+
+	s.events = append(events, s.events[cut:]...)
+}
+
+// ack marks the events up to the given sequence number as delivered.
+func (s *stream) ack(seq int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seq > s.delivered {
+		s.delivered = seq
+	}
+}
+
+// Close ends the stream after the events sent so far.
+func (s *stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	close(s.changed)
+}
+
+// next returns the events after the given sequence number, a channel closed
+// on the next change, and whether the stream has ended.
+func (s *stream) next(after int64) ([]event, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []event
+	for _, e := range s.events {
+		if e.seq > after {
+			events = append(events, e)
+		}
+	}
+
+	return events, s.changed, s.closed
+}
+
+// last returns the sequence number of the last event sent.
+func (s *stream) last() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.seq
+}
+
+// Serve writes the events after the given sequence number as an SSE
+// response, until the stream ends or the client disconnects.
+func (s *stream) Serve(c *gin.Context, after int64) {
+	startEventStream(c)
+	s.serveEvents(c, after)
+}
+
+// serveEvents writes the events of a started SSE response.
+func (s *stream) serveEvents(c *gin.Context, after int64) {
+	ctx := c.Request.Context()
+
+	for {
+		events, changed, closed := s.next(after)
+
+		for _, e := range events {
+			fmt.Fprintf(c.Writer, "id: %s\nevent: message\ndata: %s\n\n", e.id, e.data)
+			after = e.seq
+		}
+
+		c.Writer.Flush()
+		s.ack(after)
+
+		if closed {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// startEventStream writes the headers of an SSE response.
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// eventID returns the ID of an event, which identifies its stream so that the
+// stream can be resumed by Last-Event-ID.
+func eventID(streamID int64, seq int64) string {
+	return strconv.FormatInt(streamID, 10) + "-" + strconv.FormatInt(seq, 10)
+}
+
+func parseEventID(id string) (streamID int64, seq int64, ok bool) {
+	before, after, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	streamID, err := strconv.ParseInt(before, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	seq, err = strconv.ParseInt(after, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return streamID, seq, true
+}
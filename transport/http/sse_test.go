@@ -0,0 +1,47 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamKeepsUndeliveredResponses(t *testing.T) {
+	assert := assert.New(t)
+
+	st := newStream(1)
+
+	progress := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: "notifications/progress",
+		},
+	}
+
+	st.Respond(mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(int64(1)),
+		Result:  struct{}{},
+	})
+
+	for i := 0; i < 2*maxStreamEvents; i++ {
+		st.Send(progress)
+	}
+
+	// The notifications are trimmed, but not the response
+	events, _, _ := st.next(0)
+	if assert.Len(events, maxStreamEvents+1) {
+		assert.True(events[0].response)
+		assert.Equal(int64(1), events[0].seq)
+	}
+
+	// Once delivered, the response may be trimmed as well
+	st.ack(events[0].seq)
+	st.Send(progress)
+
+	events, _, _ = st.next(0)
+	if assert.Len(events, maxStreamEvents) {
+		assert.False(events[0].response)
+	}
+}
@@ -23,6 +23,47 @@ func RegisterMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 
 		ctx := c.Request.Context()
 		_, err := endpoint(ctx, req)
+
+		var errs mcpblade.ValidationErrors
+		if errors.As(err, &errs) {
+			c.JSON(http.StatusBadRequest, errs)
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+	}
+}
+
+func UpdateMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req mcpblade.UpdateMCPServerRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		_, err := endpoint(ctx, req)
+
+		var errs mcpblade.ValidationErrors
+		if errors.As(err, &errs) {
+			c.JSON(http.StatusBadRequest, errs)
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
 		if err != nil {
 			c.String(http.StatusExpectationFailed, err.Error())
 			c.Error(err)
@@ -35,6 +76,35 @@ func RegisterMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 }
 
 func UnregisterMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serverID := c.Param("server_id")
+		if serverID == "" {
+			err := errors.New("server id is required")
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		req := mcpblade.UnregisterMCPServerRequest{
+			ServerID:   serverID,
+			Persistent: c.Query("persistent") == "true",
+		}
+
+		ctx := c.Request.Context()
+		_, err := endpoint(ctx, req)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.String(http.StatusOK, "OK")
+	}
+}
+
+func HeartbeatHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		serverID := c.Param("server_id")
 		if serverID == "" {
@@ -58,6 +128,84 @@ func UnregisterMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	}
 }
 
+func AuthorizeMCPServerHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serverID := c.Param("server_id")
+		if serverID == "" {
+			err := errors.New("server id is required")
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, serverID)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		authURL, ok := resp.(string)
+		if !ok {
+			err := errors.New("invalid response type")
+			c.String(http.StatusInternalServerError, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"authorization_url": authURL,
+		})
+	}
+}
+
+// OAuthCallbackHandler completes an authorization at the redirect URI, where
+// the browser of the operator lands after giving consent.
+func OAuthCallbackHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if reason := c.Query("error"); reason != "" {
+			err := errors.New("authorization failed: " + reason)
+			if desc := c.Query("error_description"); desc != "" {
+				err = errors.New(err.Error() + ": " + desc)
+			}
+
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		req := mcpblade.CompleteAuthorizationRequest{
+			State: c.Query("state"),
+			Code:  c.Query("code"),
+		}
+
+		if req.State == "" || req.Code == "" {
+			err := errors.New("state and code are required")
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		serverID, _ := resp.(string)
+		c.String(http.StatusOK, "Authorized "+serverID+", you can close this window.")
+	}
+}
+
 func ListToolsHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
@@ -124,3 +272,103 @@ func ForwardHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
 		c.JSON(http.StatusOK, &resp)
 	}
 }
+
+func ListResourcesHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		serverID := c.Query("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, nil)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func ReadResourceHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req mcpblade.ReadResourceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		serverID := c.Query("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func ListPromptsHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		serverID := c.Query("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, nil)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
+
+func GetPromptHandler(endpoint endpoint.Endpoint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req mcpblade.GetPromptRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		serverID := c.Query("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			c.String(http.StatusExpectationFailed, err.Error())
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, &resp)
+	}
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+const (
+	// WebSocketSubprotocol is the subprotocol of MCP over WebSocket.
+	WebSocketSubprotocol = "mcp"
+
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsWriteWait    = 10 * time.Second
+
+	// wsMaxInflight is the number of requests processed concurrently per
+	// connection.
+	wsMaxInflight = 8
+)
+
+// checkOrigin accepts the upgrade requests without an Origin, i.e. not sent
+// by a browser, or from the same host, localhost, or one of the allowed
+// origins, where "*" allows any origin. Other web pages must not reach the
+// tools through the browser of the user.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			return false
+		}
+
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return true
+		}
+
+		return false
+	}
+}
+
+// MCPWebSocketHandler carries MCP JSON-RPC messages over a WebSocket in both
+// directions. Each connection is a session, which receives the notifications
+// of the aggregated servers, or of the temporary server given by the
+// server_id query. Browsers may connect from the same host, localhost or the
+// allowed origins only.
+func MCPWebSocketHandler(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, sessions *Sessions, allowedOrigins ...string) gin.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{WebSocketSubprotocol},
+		CheckOrigin:  checkOrigin(allowedOrigins),
+	}
+
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		session := sessions.Create(endpoints, c.Query("server_id"))
+		defer sessions.Delete(session.ID)
+
+		session.attached.Store(true)
+
+		go writeWebSocket(conn, session)
+
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			session.touch()
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+
+		st := session.standalone
+
+		ctx := context.WithValue(session.ctx, mcpblade.Progress, mcpblade.ProgressReporter(func(notification mcp.JSONRPCNotification) {
+			st.Send(notification)
+		}))
+
+		// Messages are processed concurrently up to wsMaxInflight, and the
+		// responses are matched to the requests by ID
+		sem := make(chan struct{}, wsMaxInflight)
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			session.touch()
+
+			if session.dispatcher.Cancel(data) {
+				continue
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-session.ctx.Done():
+				return
+			}
+
+			go func(data []byte) {
+				defer func() { <-sem }()
+
+				resp := session.dispatcher.Dispatch(ctx, data)
+				if resp != nil {
+					st.Respond(resp)
+				}
+			}(data)
+		}
+	}
+}
+
+// writeWebSocket writes the messages sent on the session stream, and pings
+// the client to keep the connection alive, until the session is closed.
+func writeWebSocket(conn *websocket.Conn, session *Session) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	var after int64
+	for {
+		events, changed, closed := session.standalone.next(after)
+
+		for _, e := range events {
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+
+			if err := conn.WriteMessage(websocket.TextMessage, e.data); err != nil {
+				conn.Close()
+				return
+			}
+
+			after = e.seq
+			session.standalone.ack(after)
+		}
+
+		if closed {
+			return
+		}
+
+		select {
+		case <-changed:
+
+		case <-ticker.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			if err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		origin   string
+		allowed  []string
+		expected bool
+	}{
+		{"", nil, true},
+		{"http://example.com:8080", nil, true},
+		{"http://localhost:3000", nil, true},
+		{"http://127.0.0.1:3000", nil, true},
+		{"http://[::1]:3000", nil, true},
+		{"https://evil.example.org", nil, false},
+		{"null", nil, false},
+		{"https://app.example.org", []string{"https://app.example.org"}, true},
+		{"https://evil.example.org", []string{"https://app.example.org"}, false},
+		{"https://evil.example.org", []string{"*"}, true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "http://example.com:8080/ws", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+
+		assert.Equal(test.expected, checkOrigin(test.allowed)(r), test.origin)
+	}
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+
+	ts, _ := newTestServer(t, newTestEndpoints(release))
+
+	dialer := websocket.Dialer{
+		Subprotocols: []string{WebSocketSubprotocol},
+	}
+
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if !assert.NoError(err) {
+		return
+	}
+	defer conn.Close()
+
+	assert.Equal(WebSocketSubprotocol, conn.Subprotocol())
+
+	// read returns the ID of a response, or the method of a notification
+	read := func() string {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err.Error()
+		}
+
+		if msg.Method != "" {
+			return msg.Method
+		}
+
+		return string(msg.ID)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(request(1, "initialize")))
+	assert.Equal("1", read())
+
+	// A blocked tool call, whose progress is sent right away, does not delay
+	// the next request
+	conn.WriteMessage(websocket.TextMessage, []byte(request(2, "tools/call")))
+	assert.Equal("notifications/progress", read())
+
+	conn.WriteMessage(websocket.TextMessage, []byte(request(3, "ping")))
+	assert.Equal("3", read())
+
+	close(release)
+	assert.Equal("2", read())
+}
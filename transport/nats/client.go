@@ -0,0 +1,140 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nats-io/nats.go"
+
+	"github.com/flarexio/mcpblade"
+)
+
+// DefaultRequestTimeout is the time to wait for a response if the context has
+// no deadline, restarted by every progress notification received before it.
+const DefaultRequestTimeout = 30 * time.Second
+
+var ErrSubjectRequired = errors.New("subject is required for nats transport")
+
+// NewTransportFactory returns the factory for backend MCP servers with the
+// nats transport type, which are reached at MCPServerConfig.Subject.
+func NewTransportFactory(nc *nats.Conn) mcpblade.TransportFactory {
+	return func(config mcpblade.MCPServerConfig) (transport.Interface, error) {
+		if config.Subject == "" {
+			return nil, ErrSubjectRequired
+		}
+
+		return NewMCPTransport(nc, config.Subject), nil
+	}
+}
+
+// MCPTransport is an mcp-go client transport that sends JSON-RPC messages to
+// an MCP server behind a NATS subject using request/reply. Server notifications
+// are received on the subject suffixed with ".notifications".
+type MCPTransport struct {
+	nc      *nats.Conn
+	subject string
+
+	sub     *nats.Subscription
+	handler func(mcp.JSONRPCNotification)
+	mu      sync.RWMutex
+}
+
+func NewMCPTransport(nc *nats.Conn, subject string) *MCPTransport {
+	return &MCPTransport{
+		nc:      nc,
+		subject: subject,
+	}
+}
+
+func (t *MCPTransport) Start(ctx context.Context) error {
+	sub, err := t.nc.Subscribe(t.subject+".notifications", func(msg *nats.Msg) {
+		var notification mcp.JSONRPCNotification
+		if err := json.Unmarshal(msg.Data, &notification); err != nil {
+			return
+		}
+
+		t.handleNotification(notification)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.sub = sub
+	t.mu.Unlock()
+
+	return nil
+}
+
+func (t *MCPTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	data, err := json.Marshal(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	req := nats.NewMsg(t.subject)
+	req.Data = data
+
+	// Progress of the request is relayed like any other server notification
+	msg, err := requestStream(ctx, t.nc, req, DefaultRequestTimeout, t.handleNotification)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Error(msg); err != nil {
+		return nil, err
+	}
+
+	var resp transport.JSONRPCResponse
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (t *MCPTransport) handleNotification(notification mcp.JSONRPCNotification) {
+	t.mu.RLock()
+	handler := t.handler
+	t.mu.RUnlock()
+
+	if handler != nil {
+		handler(notification)
+	}
+}
+
+func (t *MCPTransport) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	data, err := json.Marshal(&notification)
+	if err != nil {
+		return err
+	}
+
+	return t.nc.Publish(t.subject, data)
+}
+
+func (t *MCPTransport) SetNotificationHandler(handler func(notification mcp.JSONRPCNotification)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.handler = handler
+}
+
+func (t *MCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.sub == nil {
+		return nil
+	}
+
+	err := t.sub.Unsubscribe()
+	t.sub = nil
+
+	return err
+}
@@ -0,0 +1,145 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/stretchr/testify/assert"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+func result(req mcpE.JSONRPCRequest, result any) mcp.JSONRPCMessage {
+	return mcp.JSONRPCResponse{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+func TestMCPTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	nc := newTestConn(t)
+
+	srv, err := micro.AddService(nc, micro.Config{
+		Name:    "mcpblade",
+		Version: "1.0.0",
+	})
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer srv.Stop()
+
+	release := make(chan struct{})
+
+	endpoints := map[mcp.MCPMethod]mcpE.MCPEndpoint{
+		mcp.MethodInitialize: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			return result(req, mcp.InitializeResult{
+				ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+				ServerInfo: mcp.Implementation{
+					Name:    "backend",
+					Version: "1.0.0",
+				},
+			})
+		},
+		mcp.MethodPing: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			return result(req, struct{}{})
+		},
+		mcp.MethodToolsCall: func(ctx context.Context, req mcpE.JSONRPCRequest) mcp.JSONRPCMessage {
+			var params mcp.CallToolParams
+			json.Unmarshal(req.Params, &params)
+
+			if params.Name == "slow" {
+				<-release
+			}
+
+			return result(req, mcp.NewToolResultText(params.Name))
+		},
+	}
+
+	topic := "edges.test.mcpblade"
+	AddMCPEndpoint(srv.AddGroup(topic), endpoints)
+
+	c := client.NewClient(NewMCPTransport(nc, topic+".mcp"))
+
+	ctx := context.Background()
+
+	if err := c.Start(ctx); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer c.Close()
+
+	notifications := make(chan mcp.JSONRPCNotification, 1)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		notifications <- notification
+	})
+
+	init, err := c.Initialize(ctx, mcp.InitializeRequest{})
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	assert.Equal("backend", init.ServerInfo.Name)
+
+	// A long tool call does not delay the health check
+	slow := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "slow"
+
+		result, _ := c.CallTool(ctx, req)
+		slow <- result
+	}()
+
+	pingCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	assert.NoError(c.Ping(pingCtx))
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "echo"
+
+	res, err := c.CallTool(ctx, req)
+	if assert.NoError(err) && assert.Len(res.Content, 1) {
+		assert.Equal("echo", res.Content[0].(mcp.TextContent).Text)
+	}
+
+	close(release)
+
+	select {
+	case res := <-slow:
+		if assert.NotNil(res) && assert.Len(res.Content, 1) {
+			assert.Equal("slow", res.Content[0].(mcp.TextContent).Text)
+		}
+
+	case <-time.After(time.Second):
+		assert.Fail("slow tool call not completed")
+	}
+
+	// Server notifications are relayed to the client
+	publish := NotificationPublisher(nc, topic+".mcp.notifications")
+	publish("", mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: mcp.MethodNotificationToolsListChanged,
+		},
+	})
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(mcp.MethodNotificationToolsListChanged, notification.Method)
+
+	case <-time.After(time.Second):
+		assert.Fail("notification not relayed")
+	}
+}
@@ -16,10 +16,22 @@ import (
 func MakeEndpoints(nc *nats.Conn, prefix string) *mcpblade.EndpointSet {
 	return &mcpblade.EndpointSet{
 		RegisterMCPServer:   RegisterMCPServerEndpoint(nc, prefix+".register_mcp_server"),
+		UpdateMCPServer:     UpdateMCPServerEndpoint(nc, prefix+".update_mcp_server"),
 		UnregisterMCPServer: UnregisterMCPServerEndpoint(nc, prefix+".unregister_mcp_server"),
+		Heartbeat:           HeartbeatEndpoint(nc, prefix+".heartbeat"),
 		ListTools:           ListToolsEndpoint(nc, prefix+".list_tools"),
 		SearchTools:         SearchToolsEndpoint(nc, prefix+".search_tools"),
 		Forward:             ForwardEndpoint(nc, prefix+".forward"),
+
+		AuthorizeMCPServer:    AuthorizeMCPServerEndpoint(nc, prefix+".authorize_mcp_server"),
+		CompleteAuthorization: CompleteAuthorizationEndpoint(nc, prefix+".complete_authorization"),
+
+		ListResources:         ListResourcesEndpoint(nc, prefix+".list_resources"),
+		ListResourceTemplates: ListResourceTemplatesEndpoint(nc, prefix+".list_resource_templates"),
+		ReadResource:          ReadResourceEndpoint(nc, prefix+".read_resource"),
+
+		ListPrompts: ListPromptsEndpoint(nc, prefix+".list_prompts"),
+		GetPrompt:   GetPromptEndpoint(nc, prefix+".get_prompt"),
 	}
 }
 
@@ -35,16 +47,73 @@ func RegisterMCPServerEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
 			return nil, err
 		}
 
-		resp, err := nc.Request(topic, data, nats.DefaultTimeout)
+		// Connecting the server may take longer than a plain request
+		resp, err := nc.Request(topic, data, DefaultRequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		return string(resp.Data), nil
+	}
+}
+
+func UpdateMCPServerEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(mcpblade.UpdateMCPServerRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		data, err := json.Marshal(&req)
 		if err != nil {
 			return nil, err
 		}
 
+		resp, err := nc.Request(topic, data, DefaultRequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
 		return string(resp.Data), nil
 	}
 }
 
 func UnregisterMCPServerEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(mcpblade.UnregisterMCPServerRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Data = []byte(req.ServerID)
+
+		if req.Persistent {
+			msg.Header.Set("persistent", "true")
+		}
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		return string(resp.Data), nil
+	}
+}
+
+func HeartbeatEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
 	return func(ctx context.Context, request any) (any, error) {
 		serverID, ok := request.(string)
 		if !ok {
@@ -56,6 +125,59 @@ func UnregisterMCPServerEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint
 			return nil, err
 		}
 
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		return string(resp.Data), nil
+	}
+}
+
+func AuthorizeMCPServerEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		serverID, ok := request.(string)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		// Discovering the authorization server may take longer than a plain
+		// request
+		resp, err := nc.Request(topic, []byte(serverID), DefaultRequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		return string(resp.Data), nil
+	}
+}
+
+func CompleteAuthorizationEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(mcpblade.CompleteAuthorizationRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		data, err := json.Marshal(&req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Connecting the authorized server may take longer than a plain
+		// request
+		resp, err := nc.Request(topic, data, DefaultRequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
 		return string(resp.Data), nil
 	}
 }
@@ -136,7 +258,14 @@ func ForwardEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
 		msg.Header = header
 		msg.Data = data
 
-		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		var handler func(mcp.JSONRPCNotification)
+
+		report, ok := ctx.Value(mcpblade.Progress).(mcpblade.ProgressReporter)
+		if ok && req.Params.Meta != nil && req.Params.Meta.ProgressToken != nil {
+			handler = report
+		}
+
+		resp, err := requestStream(ctx, nc, msg, DefaultRequestTimeout, handler)
 		if err != nil {
 			return nil, err
 		}
@@ -147,6 +276,175 @@ func ForwardEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
 	}
 }
 
+func ListResourcesEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		header := make(nats.Header)
+
+		serverID, ok := ctx.Value(mcpblade.ServerID).(string)
+		if ok {
+			header.Set("server_id", serverID)
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Header = header
+		msg.Data = nil
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		var resources []mcp.Resource
+		if err := json.Unmarshal(resp.Data, &resources); err != nil {
+			return nil, err
+		}
+
+		return resources, nil
+	}
+}
+
+func ListResourceTemplatesEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		header := make(nats.Header)
+
+		serverID, ok := ctx.Value(mcpblade.ServerID).(string)
+		if ok {
+			header.Set("server_id", serverID)
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Header = header
+		msg.Data = nil
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		var templates []mcp.ResourceTemplate
+		if err := json.Unmarshal(resp.Data, &templates); err != nil {
+			return nil, err
+		}
+
+		return templates, nil
+	}
+}
+
+func ReadResourceEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(mcp.ReadResourceRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		data, err := json.Marshal(&req)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make(nats.Header)
+
+		serverID, ok := ctx.Value(mcpblade.ServerID).(string)
+		if ok {
+			header.Set("server_id", serverID)
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Header = header
+		msg.Data = data
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		raw := json.RawMessage(resp.Data)
+
+		return mcp.ParseReadResourceResult(&raw)
+	}
+}
+
+func ListPromptsEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		header := make(nats.Header)
+
+		serverID, ok := ctx.Value(mcpblade.ServerID).(string)
+		if ok {
+			header.Set("server_id", serverID)
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Header = header
+		msg.Data = nil
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		var prompts []mcp.Prompt
+		if err := json.Unmarshal(resp.Data, &prompts); err != nil {
+			return nil, err
+		}
+
+		return prompts, nil
+	}
+}
+
+func GetPromptEndpoint(nc *nats.Conn, topic string) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(mcp.GetPromptRequest)
+		if !ok {
+			return nil, errors.New("invalid request")
+		}
+
+		data, err := json.Marshal(&req)
+		if err != nil {
+			return nil, err
+		}
+
+		header := make(nats.Header)
+
+		serverID, ok := ctx.Value(mcpblade.ServerID).(string)
+		if ok {
+			header.Set("server_id", serverID)
+		}
+
+		msg := nats.NewMsg(topic)
+		msg.Header = header
+		msg.Data = data
+
+		resp, err := nc.RequestMsg(msg, nats.DefaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := Error(resp); err != nil {
+			return nil, err
+		}
+
+		raw := json.RawMessage(resp.Data)
+
+		return mcp.ParseGetPromptResult(&raw)
+	}
+}
+
 func Error(msg *nats.Msg) error {
 	if msg == nil {
 		return errors.New("nil message")
@@ -0,0 +1,41 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nats-io/nats.go/micro"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+// MCPHandler serves MCP JSON-RPC messages over NATS request/reply, so that
+// mcpblade itself can be used as a nats backend by another mcpblade.
+func MCPHandler(endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint, inflight *mcpE.InflightRequests) micro.HandlerFunc {
+	dispatcher := mcpE.NewDispatcher(endpoints)
+
+	return func(r micro.Request) {
+		ctx, done := trackRequest(inflight, r)
+		defer done()
+
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		if report, ok := progressReporter(r); ok {
+			ctx = context.WithValue(ctx, mcpblade.Progress, report)
+		}
+
+		resp := dispatcher.Dispatch(ctx, r.Data())
+
+		// Notifications are published without a reply subject
+		if resp == nil {
+			return
+		}
+
+		r.RespondJSON(resp)
+	}
+}
@@ -0,0 +1,43 @@
+package nats
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nats-io/nats.go"
+
+	"github.com/flarexio/mcpblade"
+)
+
+// NotificationPublisher returns a handler that publishes relayed notifications
+// to the given subject, with the temporary server ID in the server_id header.
+func NotificationPublisher(nc *nats.Conn, subject string) mcpblade.NotificationHandler {
+	return func(serverID string, notification mcp.JSONRPCNotification) {
+		data, err := json.Marshal(&notification)
+		if err != nil {
+			return
+		}
+
+		msg := nats.NewMsg(subject)
+		msg.Data = data
+
+		if serverID != "" {
+			msg.Header.Set("server_id", serverID)
+		}
+
+		nc.PublishMsg(msg)
+	}
+}
+
+// SubscribeNotifications subscribes to notifications published by
+// NotificationPublisher and passes them to the handler.
+func SubscribeNotifications(nc *nats.Conn, subject string, handler mcpblade.NotificationHandler) (*nats.Subscription, error) {
+	return nc.Subscribe(subject, func(msg *nats.Msg) {
+		var notification mcp.JSONRPCNotification
+		if err := json.Unmarshal(msg.Data, &notification); err != nil {
+			return
+		}
+
+		handler(msg.Header.Get("server_id"), notification)
+	})
+}
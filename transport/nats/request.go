@@ -0,0 +1,173 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+
+	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
+)
+
+const (
+	// ProgressHeader asks the responder to publish progress notifications to
+	// the reply inbox before the response.
+	ProgressHeader = "progress"
+
+	// MessageTypeHeader marks the notifications published to a reply inbox.
+	MessageTypeHeader       = "message_type"
+	MessageTypeNotification = "notification"
+)
+
+// requestStream sends msg and waits for the response on its own reply inbox until
+// ctx is done. If ctx has no deadline, the request times out after timeout
+// without any message. Notifications published to the inbox before the
+// response are passed to handler, if set, and restart the timeout, so that
+// long-running requests reporting progress do not time out. If the response
+// is not awaited any longer, the reply inbox is published to the subject
+// suffixed with ".cancel" to cancel the request.
+func requestStream(ctx context.Context, nc *nats.Conn, msg *nats.Msg, timeout time.Duration, handler func(mcp.JSONRPCNotification)) (*nats.Msg, error) {
+	inbox := nats.NewInbox()
+
+	sub, err := nc.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	if msg.Header == nil {
+		msg.Header = make(nats.Header)
+	}
+
+	if handler != nil {
+		msg.Header.Set(ProgressHeader, "true")
+	}
+
+	msg.Reply = inbox
+
+	if err := nc.PublishMsg(msg); err != nil {
+		return nil, err
+	}
+
+	for {
+		resp, err := nextMsg(ctx, sub, timeout)
+		if err != nil {
+			nc.Publish(msg.Subject+".cancel", []byte(inbox))
+
+			return nil, err
+		}
+
+		if resp.Header.Get(MessageTypeHeader) != MessageTypeNotification {
+			return resp, nil
+		}
+
+		var notification mcp.JSONRPCNotification
+		if err := json.Unmarshal(resp.Data, &notification); err != nil {
+			continue
+		}
+
+		if handler != nil {
+			handler(notification)
+		}
+	}
+}
+
+func nextMsg(ctx context.Context, sub *nats.Subscription, timeout time.Duration) (*nats.Msg, error) {
+	if _, ok := ctx.Deadline(); ok {
+		return sub.NextMsgWithContext(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	msg, err := sub.NextMsgWithContext(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, nats.ErrTimeout
+	}
+
+	return msg, err
+}
+
+// trackRequest returns the context of a request, tracked by its reply inbox.
+func trackRequest(inflight *mcpE.InflightRequests, r micro.Request) (context.Context, context.CancelFunc) {
+	// Published without a reply subject, e.g. notifications
+	if r.Reply() == "" {
+		return context.Background(), func() {}
+	}
+
+	return inflight.Track(context.Background(), r.Reply())
+}
+
+// CancelHandler cancels the in-flight request with the reply inbox given as
+// data. Cancellations need their own subject, as the request they cancel may
+// be waiting for a free slot of its endpoint, see Concurrent.
+func CancelHandler(inflight *mcpE.InflightRequests) micro.HandlerFunc {
+	return func(r micro.Request) {
+		inflight.Cancel(string(r.Data()))
+	}
+}
+
+// DefaultMaxInflight is the number of requests an endpoint processes
+// concurrently.
+const DefaultMaxInflight = 64
+
+// Concurrent handles each request in its own goroutine, up to maxInflight or
+// DefaultMaxInflight at a time, as the messages of an endpoint are otherwise
+// handled one after another, and a slow tool call would delay all others.
+// Once all slots are taken, the following requests wait for a free one.
+func Concurrent(handler micro.HandlerFunc, maxInflight ...int) micro.HandlerFunc {
+	n := DefaultMaxInflight
+	if len(maxInflight) > 0 && maxInflight[0] > 0 {
+		n = maxInflight[0]
+	}
+
+	sem := make(chan struct{}, n)
+
+	return func(r micro.Request) {
+		sem <- struct{}{}
+
+		go func() {
+			defer func() { <-sem }()
+
+			handler(&detachedRequest{r})
+		}()
+	}
+}
+
+// detachedRequest is a request responded after its handler returned. Errors
+// are responded like with micro.Request, but not recorded on the request,
+// which the service reads as soon as the handler returned.
+type detachedRequest struct {
+	micro.Request
+}
+
+func (r *detachedRequest) Error(code, description string, data []byte, opts ...micro.RespondOpt) error {
+	headers := micro.Headers{
+		micro.ErrorHeader:     []string{description},
+		micro.ErrorCodeHeader: []string{code},
+	}
+
+	return r.Respond(data, append(opts, micro.WithHeaders(headers))...)
+}
+
+// progressReporter returns a reporter publishing progress notifications to
+// the reply inbox of r, if the requester asked for them.
+func progressReporter(r micro.Request) (mcpblade.ProgressReporter, bool) {
+	if r.Headers().Get(ProgressHeader) == "" {
+		return nil, false
+	}
+
+	headers := micro.Headers{
+		MessageTypeHeader: []string{MessageTypeNotification},
+	}
+
+	return func(notification mcp.JSONRPCNotification) {
+		r.RespondJSON(&notification, micro.WithHeaders(headers))
+	}, true
+}
@@ -0,0 +1,99 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConn starts an embedded NATS server and connects to it.
+func newTestConn(t *testing.T) *nats.Conn {
+	srv, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   -1,
+		NoLog:  true,
+		NoSigs: true,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(nc.Close)
+
+	return nc
+}
+
+func TestConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	nc := newTestConn(t)
+
+	srv, err := micro.AddService(nc, micro.Config{
+		Name:    "test",
+		Version: "1.0.0",
+	})
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer srv.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	handler := func(r micro.Request) {
+		if string(r.Data()) == "block" {
+			<-release
+		}
+
+		if string(r.Data()) == "fail" {
+			r.Error("417", "failed", nil)
+			return
+		}
+
+		r.Respond(r.Data())
+	}
+
+	srv.AddEndpoint("echo", Concurrent(handler, 2))
+
+	// A blocked request does not delay the next one
+	blocked, err := nc.Request("echo", []byte("block"), 10*time.Millisecond)
+	assert.ErrorIs(err, nats.ErrTimeout)
+	assert.Nil(blocked)
+
+	resp, err := nc.Request("echo", []byte("hello"), time.Second)
+	if assert.NoError(err) {
+		assert.Equal("hello", string(resp.Data))
+	}
+
+	// Errors are responded after the handler returned
+	resp, err = nc.Request("echo", []byte("fail"), time.Second)
+	if assert.NoError(err) {
+		assert.EqualError(Error(resp), "417:failed")
+	}
+
+	// Once all slots are taken, the next request waits for a free one
+	_, err = nc.Request("echo", []byte("block"), 10*time.Millisecond)
+	assert.ErrorIs(err, nats.ErrTimeout)
+
+	_, err = nc.Request("echo", []byte("hello"), 100*time.Millisecond)
+	assert.ErrorIs(err, nats.ErrTimeout)
+}
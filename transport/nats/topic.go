@@ -1,15 +1,42 @@
 package nats
 
 import (
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/nats-io/nats.go/micro"
 
 	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
 )
 
+// AddEndpoints adds the endpoints of the service, which process their
+// requests concurrently, see Concurrent.
 func AddEndpoints(group micro.Group, endpoints mcpblade.EndpointSet) {
-	group.AddEndpoint("register_mcp_server", RegisterMCPServerHandler(endpoints.RegisterMCPServer))
-	group.AddEndpoint("unregister_mcp_server", UnregisterMCPServerHandler(endpoints.UnregisterMCPServer))
-	group.AddEndpoint("list_tools", ListToolsHandler(endpoints.ListTools))
-	group.AddEndpoint("search_tools", SearchToolsHandler(endpoints.SearchTools))
-	group.AddEndpoint("forward", ForwardHandler(endpoints.Forward))
+	inflight := mcpE.NewInflightRequests()
+
+	group.AddEndpoint("register_mcp_server", Concurrent(RegisterMCPServerHandler(endpoints.RegisterMCPServer)))
+	group.AddEndpoint("update_mcp_server", Concurrent(UpdateMCPServerHandler(endpoints.UpdateMCPServer)))
+	group.AddEndpoint("unregister_mcp_server", Concurrent(UnregisterMCPServerHandler(endpoints.UnregisterMCPServer)))
+	group.AddEndpoint("heartbeat", Concurrent(HeartbeatHandler(endpoints.Heartbeat)))
+	group.AddEndpoint("authorize_mcp_server", Concurrent(AuthorizeMCPServerHandler(endpoints.AuthorizeMCPServer)))
+	group.AddEndpoint("complete_authorization", Concurrent(CompleteAuthorizationHandler(endpoints.CompleteAuthorization)))
+	group.AddEndpoint("list_tools", Concurrent(ListToolsHandler(endpoints.ListTools)))
+	group.AddEndpoint("search_tools", Concurrent(SearchToolsHandler(endpoints.SearchTools)))
+	group.AddEndpoint("forward", Concurrent(ForwardHandler(endpoints.Forward, inflight)))
+	group.AddEndpoint("cancel_forward", CancelHandler(inflight), micro.WithEndpointSubject("forward.cancel"))
+	group.AddEndpoint("list_resources", Concurrent(ListResourcesHandler(endpoints.ListResources)))
+	group.AddEndpoint("list_resource_templates", Concurrent(ListResourceTemplatesHandler(endpoints.ListResourceTemplates)))
+	group.AddEndpoint("read_resource", Concurrent(ReadResourceHandler(endpoints.ReadResource)))
+	group.AddEndpoint("list_prompts", Concurrent(ListPromptsHandler(endpoints.ListPrompts)))
+	group.AddEndpoint("get_prompt", Concurrent(GetPromptHandler(endpoints.GetPrompt)))
+}
+
+// AddMCPEndpoint adds the MCP endpoint, which processes its messages
+// concurrently, so that a long tool call does not delay the health checks of
+// the clients using it as a nats backend.
+func AddMCPEndpoint(group micro.Group, endpoints map[mcp.MCPMethod]mcpE.MCPEndpoint) {
+	inflight := mcpE.NewInflightRequests()
+
+	group.AddEndpoint("mcp", Concurrent(MCPHandler(endpoints, inflight)))
+	group.AddEndpoint("cancel_mcp", CancelHandler(inflight), micro.WithEndpointSubject("mcp.cancel"))
 }
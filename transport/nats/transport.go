@@ -9,6 +9,8 @@ import (
 	"github.com/nats-io/nats.go/micro"
 
 	"github.com/flarexio/mcpblade"
+
+	mcpE "github.com/flarexio/mcpblade/mcp"
 )
 
 func RegisterMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
@@ -30,7 +32,50 @@ func RegisterMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
 	}
 }
 
+func UpdateMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		var req mcpblade.UpdateMCPServerRequest
+		if err := json.Unmarshal(r.Data(), &req); err != nil {
+			r.Error("400", err.Error(), nil)
+			return
+		}
+
+		ctx := context.Background()
+		_, err := endpoint(ctx, req)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		r.Respond([]byte("OK"))
+	}
+}
+
 func UnregisterMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		serverID := string(r.Data())
+		if serverID == "" {
+			r.Error("400", "server id is required", nil)
+			return
+		}
+
+		req := mcpblade.UnregisterMCPServerRequest{
+			ServerID:   serverID,
+			Persistent: r.Headers().Get("persistent") == "true",
+		}
+
+		ctx := context.Background()
+		_, err := endpoint(ctx, req)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		r.Respond([]byte("OK"))
+	}
+}
+
+func HeartbeatHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
 	return func(r micro.Request) {
 		serverID := string(r.Data())
 		if serverID == "" {
@@ -49,6 +94,59 @@ func UnregisterMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
 	}
 }
 
+func AuthorizeMCPServerHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		serverID := string(r.Data())
+		if serverID == "" {
+			r.Error("400", "server id is required", nil)
+			return
+		}
+
+		ctx := context.Background()
+		resp, err := endpoint(ctx, serverID)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		authURL, ok := resp.(string)
+		if !ok {
+			r.Error("500", "invalid response type", nil)
+			return
+		}
+
+		r.Respond([]byte(authURL))
+	}
+}
+
+// CompleteAuthorizationHandler completes an authorization with the state and
+// code of a callback received elsewhere, e.g. by a redirect URI that is not
+// served by this service, and responds the ID of the authorized server.
+func CompleteAuthorizationHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		var req mcpblade.CompleteAuthorizationRequest
+		if err := json.Unmarshal(r.Data(), &req); err != nil {
+			r.Error("400", err.Error(), nil)
+			return
+		}
+
+		if req.State == "" || req.Code == "" {
+			r.Error("400", "state and code are required", nil)
+			return
+		}
+
+		ctx := context.Background()
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		serverID, _ := resp.(string)
+		r.Respond([]byte(serverID))
+	}
+}
+
 func ListToolsHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
 	return func(r micro.Request) {
 		ctx := context.Background()
@@ -99,14 +197,144 @@ func SearchToolsHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
 	}
 }
 
-func ForwardHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+func ForwardHandler(endpoint endpoint.Endpoint, inflight *mcpE.InflightRequests) micro.HandlerFunc {
 	return func(r micro.Request) {
+		ctx, done := trackRequest(inflight, r)
+		defer done()
+
 		var req mcpblade.ForwardRequest
 		if err := json.Unmarshal(r.Data(), &req); err != nil {
 			r.Error("400", err.Error(), nil)
 			return
 		}
 
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		if report, ok := progressReporter(r); ok {
+			ctx = context.WithValue(ctx, mcpblade.Progress, report)
+		}
+
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		r.RespondJSON(&resp)
+	}
+}
+
+func ListResourcesHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		ctx := context.Background()
+
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, nil)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		resources, ok := resp.([]mcp.Resource)
+		if !ok {
+			r.Error("500", "invalid response type", nil)
+			return
+		}
+
+		r.RespondJSON(&resources)
+	}
+}
+
+func ListResourceTemplatesHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		ctx := context.Background()
+
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, nil)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		templates, ok := resp.([]mcp.ResourceTemplate)
+		if !ok {
+			r.Error("500", "invalid response type", nil)
+			return
+		}
+
+		r.RespondJSON(&templates)
+	}
+}
+
+func ReadResourceHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		var req mcpblade.ReadResourceRequest
+		if err := json.Unmarshal(r.Data(), &req); err != nil {
+			r.Error("400", err.Error(), nil)
+			return
+		}
+
+		ctx := context.Background()
+
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, req)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		r.RespondJSON(&resp)
+	}
+}
+
+func ListPromptsHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		ctx := context.Background()
+
+		serverID := r.Headers().Get("server_id")
+		if serverID != "" {
+			ctx = context.WithValue(ctx, mcpblade.ServerID, serverID)
+		}
+
+		resp, err := endpoint(ctx, nil)
+		if err != nil {
+			r.Error("417", err.Error(), nil)
+			return
+		}
+
+		prompts, ok := resp.([]mcp.Prompt)
+		if !ok {
+			r.Error("500", "invalid response type", nil)
+			return
+		}
+
+		r.RespondJSON(&prompts)
+	}
+}
+
+func GetPromptHandler(endpoint endpoint.Endpoint) micro.HandlerFunc {
+	return func(r micro.Request) {
+		var req mcpblade.GetPromptRequest
+		if err := json.Unmarshal(r.Data(), &req); err != nil {
+			r.Error("400", err.Error(), nil)
+			return
+		}
+
 		ctx := context.Background()
 
 		serverID := r.Headers().Get("server_id")
@@ -0,0 +1,75 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go/micro"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/flarexio/mcpblade"
+)
+
+func TestAuthorizationEndpoints(t *testing.T) {
+	assert := assert.New(t)
+
+	nc := newTestConn(t)
+
+	srv, err := micro.AddService(nc, micro.Config{
+		Name:    "mcpblade",
+		Version: "1.0.0",
+	})
+
+	if err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+	defer srv.Stop()
+
+	endpoints := mcpblade.EndpointSet{
+		AuthorizeMCPServer: func(ctx context.Context, request any) (any, error) {
+			if request.(string) != "remote" {
+				return nil, mcpblade.ErrServerNotFound
+			}
+
+			return "https://auth.example.com/authorize?state=abc", nil
+		},
+		CompleteAuthorization: func(ctx context.Context, request any) (any, error) {
+			req := request.(mcpblade.CompleteAuthorizationRequest)
+			if req.State != "abc" {
+				return nil, errors.New("unknown state")
+			}
+
+			return "remote", nil
+		},
+	}
+
+	topic := "edges.test.mcpblade"
+	AddEndpoints(srv.AddGroup(topic), endpoints)
+
+	var svc mcpblade.Service
+	svc = mcpblade.ProxyMiddleware(MakeEndpoints(nc, topic))(svc)
+
+	ctx := context.Background()
+
+	authURL, err := svc.AuthorizeMCPServer(ctx, "remote")
+	if assert.NoError(err) {
+		assert.Equal("https://auth.example.com/authorize?state=abc", authURL)
+	}
+
+	_, err = svc.AuthorizeMCPServer(ctx, "unknown")
+	assert.ErrorContains(err, mcpblade.ErrServerNotFound.Error())
+
+	serverID, err := svc.CompleteAuthorization(ctx, "abc", "code")
+	if assert.NoError(err) {
+		assert.Equal("remote", serverID)
+	}
+
+	_, err = svc.CompleteAuthorization(ctx, "other", "code")
+	assert.ErrorContains(err, "unknown state")
+
+	// The state and the code are both required
+	_, err = svc.CompleteAuthorization(ctx, "abc", "")
+	assert.Error(err)
+}
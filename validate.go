@@ -0,0 +1,391 @@
+package mcpblade
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ValidationError is a problem of a config field, given by its dotted path,
+// e.g. mcpServers.time.transport. The line is set when the config was
+// decoded from YAML.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (e ValidationError) Error() string {
+	msg := e.Field + ": " + e.Message
+	if e.Line > 0 {
+		msg = "line " + strconv.Itoa(e.Line) + ": " + msg
+	}
+
+	return msg
+}
+
+// ValidationErrors holds all the problems of a config, and matches
+// ErrInvalidConfig.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return ErrInvalidConfig.Error() + ":\n  " + strings.Join(msgs, "\n  ")
+}
+
+func (errs ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// position is where a field was found in a YAML config.
+type position struct {
+	line   int
+	column int
+}
+
+// UnmarshalYAML decodes the config, and keeps the positions of the fields,
+// and the unknown fields anywhere in the document, for Validate.
+func (cfg *Config) UnmarshalYAML(value *yaml.Node) error {
+	type config Config
+	if err := value.Decode((*config)(cfg)); err != nil {
+		return err
+	}
+
+	cfg.positions = make(map[string]position)
+	walkYAML(value, "", func(path string, key *yaml.Node) {
+		cfg.positions[path] = position{key.Line, key.Column}
+	})
+
+	cfg.unknownFields = unknownFields(value, reflect.TypeFor[Config](), "")
+
+	return nil
+}
+
+// unknownFields returns the dotted paths of the mapping keys below node that
+// do not match a field of the type decoded from it, e.g. vectr or
+// vector.embeding.
+func unknownFields(node *yaml.Node, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+
+		return path + "." + key
+	}
+
+	var unknown []string
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			unknown = append(unknown, unknownFields(n, t, path)...)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+
+			switch t.Kind() {
+			case reflect.Struct:
+				field, ok := yamlField(t, key)
+				if !ok {
+					unknown = append(unknown, join(key))
+					continue
+				}
+
+				unknown = append(unknown, unknownFields(value, field, join(key))...)
+
+			case reflect.Map:
+				unknown = append(unknown, unknownFields(value, t.Elem(), join(key))...)
+			}
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+
+		for i, n := range node.Content {
+			unknown = append(unknown, unknownFields(n, t.Elem(), join(strconv.Itoa(i)))...)
+		}
+	}
+
+	return unknown
+}
+
+// Validate checks the config, and returns all its problems as
+// ValidationErrors.
+func (cfg Config) Validate() error {
+	var errs ValidationErrors
+
+	add := func(field, message string) {
+		err := ValidationError{
+			Field:   field,
+			Message: message,
+		}
+
+		// Missing fields are reported at their parent
+		for path := field; path != ""; path, _, _ = cutLast(path) {
+			if pos, ok := cfg.positions[path]; ok {
+				err.Line = pos.line
+				err.Column = pos.column
+				break
+			}
+		}
+
+		errs = append(errs, err)
+	}
+
+	if cfg.CacheRefreshTTL < 0 {
+		add("cacheRefreshTTL", "must not be negative")
+	}
+
+	for _, path := range cfg.unknownFields {
+		add(path, "unknown field")
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(cfg.MCPServers)) {
+		prefix := "mcpServers." + id
+
+		if id == "" {
+			add(prefix, ErrInvalidServerID.Error())
+		}
+
+		var serverErrs ValidationErrors
+		if errors.As(cfg.MCPServers[id].Validate(), &serverErrs) {
+			for _, err := range serverErrs {
+				add(prefix+"."+err.Field, err.Message)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		slices.SortStableFunc(errs, func(a, b ValidationError) int {
+			return a.Line - b.Line
+		})
+
+		return errs
+	}
+
+	return nil
+}
+
+// Validate checks the config of an MCP server, and returns all its problems
+// as ValidationErrors.
+func (cfg MCPServerConfig) Validate() error {
+	var errs ValidationErrors
+
+	add := func(field, message string) {
+		errs = append(errs, ValidationError{
+			Field:   field,
+			Message: message,
+		})
+	}
+
+	// Fields that do not apply to the transport
+	unused := func(fields ...string) {
+		set := map[string]bool{
+			"command": cfg.Command != "",
+			"url":     cfg.URL != "",
+			"subject": cfg.Subject != "",
+			"args":    len(cfg.Arguments) > 0,
+			"env":     len(cfg.Environment) > 0,
+			"headers": len(cfg.Headers) > 0,
+			"tls":     cfg.TLS != nil,
+			"proxy":   cfg.Proxy != "",
+			"timeout": cfg.Timeout != 0,
+			"oauth":   cfg.OAuth != nil,
+		}
+
+		for _, field := range fields {
+			if set[field] {
+				add(field, "not used by the "+string(cfg.Transport)+" transport")
+			}
+		}
+	}
+
+	switch cfg.Transport {
+	case "":
+		add("transport", "is required")
+
+	case TransportTypeStdio:
+		if cfg.Command == "" {
+			add("command", "is required")
+		}
+
+		unused("url", "subject", "headers", "tls", "proxy", "timeout", "oauth")
+
+	case TransportTypeSSE, TransportTypeStreamableHTTP:
+		if cfg.URL == "" {
+			add("url", "is required")
+		} else if err := validateURL(cfg.URL); err != nil {
+			add("url", err.Error())
+		}
+
+		unused("command", "subject", "args", "env")
+
+		if tls := cfg.TLS; tls != nil && (tls.Cert == "") != (tls.Key == "") {
+			add("tls", "cert and key must be set together")
+		}
+
+		if cfg.Proxy != "" && !strings.Contains(cfg.Proxy, "${") {
+			if u, err := url.Parse(cfg.Proxy); err != nil || u.Host == "" {
+				add("proxy", "invalid URL")
+			}
+		}
+
+		if cfg.Timeout < 0 {
+			add("timeout", "must not be negative")
+		}
+
+		if oauth := cfg.OAuth; oauth != nil {
+			if oauth.ClientSecret != "" && oauth.ClientID == "" {
+				add("oauth.clientSecret", "requires clientID")
+			}
+
+			if oauth.RedirectURI != "" && !strings.Contains(oauth.RedirectURI, "${") {
+				if err := transport.ValidateRedirectURI(oauth.RedirectURI); err != nil {
+					add("oauth.redirectURI", "must be an https or localhost URL")
+				}
+			}
+
+			if oauth.MetadataURL != "" {
+				if err := validateURL(oauth.MetadataURL); err != nil {
+					add("oauth.metadataURL", err.Error())
+				}
+			}
+		}
+
+	case TransportTypeNATS:
+		if cfg.Subject == "" {
+			add("subject", "is required")
+		}
+
+		unused("command", "url", "args", "env", "headers", "tls", "proxy", "timeout", "oauth")
+
+	default:
+		add("transport", ErrUnsupportedTransportType.Error()+" \""+string(cfg.Transport)+"\"")
+	}
+
+	switch cfg.RestartPolicy {
+	case "", RestartPolicyAlways, RestartPolicyOnFailure, RestartPolicyNever:
+
+	default:
+		add("restart", "unsupported restart policy \""+string(cfg.RestartPolicy)+"\"")
+	}
+
+	if cfg.MaxRestarts < 0 {
+		add("maxRestarts", "must not be negative")
+	}
+
+	if cfg.TTL < 0 {
+		add("ttl", "must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateURL checks the URL of a remote server, unless it is interpolated.
+func validateURL(rawURL string) error {
+	if strings.Contains(rawURL, "${") {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("must be an http or https URL")
+	}
+
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+
+	return nil
+}
+
+// walkYAML calls fn with the dotted path and the key node of every mapping
+// key below node.
+func walkYAML(node *yaml.Node, path string, fn func(path string, key *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			walkYAML(n, path, fn)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			p := key.Value
+			if path != "" {
+				p = path + "." + key.Value
+			}
+
+			fn(p, key)
+			walkYAML(value, p, fn)
+		}
+	}
+}
+
+// yamlField returns the type of the field of a struct with the given YAML
+// name.
+func yamlField(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		// Untagged fields are decoded by their lowercased name
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+
+		if tag == name {
+			return field.Type, true
+		}
+	}
+
+	return nil, false
+}
+
+// cutLast splits a dotted path at its last element.
+func cutLast(path string) (string, string, bool) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", path, false
+	}
+
+	return path[:i], path[i+1:], true
+}
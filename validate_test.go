@@ -0,0 +1,150 @@
+package mcpblade
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	input := `mcpServers:
+  time:
+    transport: stdio
+    command: uvx
+    args: [ "mcp-server-time" ]
+  remote:
+    transport: sse
+    comand: uvx
+  local:
+    transport: stdio
+    url: http://localhost:8080/mcp
+    restart: sometimes
+  edge:
+    transport: grpc
+`
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	err := cfg.Validate()
+	assert.ErrorIs(err, ErrInvalidConfig)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		assert.Fail("expected validation errors")
+		return
+	}
+
+	assert.Equal(ValidationErrors{
+		{Field: "mcpServers.remote.url", Message: "is required", Line: 6, Column: 3},
+		{Field: "mcpServers.remote.comand", Message: "unknown field", Line: 8, Column: 5},
+		{Field: "mcpServers.local.command", Message: "is required", Line: 9, Column: 3},
+		{Field: "mcpServers.local.url", Message: "not used by the stdio transport", Line: 11, Column: 5},
+		{Field: "mcpServers.local.restart", Message: `unsupported restart policy "sometimes"`, Line: 12, Column: 5},
+		{Field: "mcpServers.edge.transport", Message: `unsupported transport type "grpc"`, Line: 14, Column: 5},
+	}, errs)
+
+	assert.Contains(err.Error(), "line 6: mcpServers.remote.url: is required")
+
+	cfg = Config{
+		MCPServers: map[string]MCPServerConfig{
+			"time": {
+				Transport: TransportTypeStdio,
+				Command:   "uvx",
+			},
+		},
+	}
+
+	assert.NoError(cfg.Validate())
+}
+
+func TestConfigValidateUnknownFields(t *testing.T) {
+	assert := assert.New(t)
+
+	input := `cacheRefreshTTL: 5m
+cacheRefershTTL: 10m
+vectr:
+  enabled: true
+vector:
+  enabled: true
+  embeding:
+    provider: ollama
+  embedding:
+    provider: ollama
+    modle: nomic-embed-text
+mcpServers:
+  remote:
+    transport: streamable-http
+    url: https://example.com/mcp
+    tls:
+      ca: /etc/ssl/ca.pem
+      insecur: true
+    oauth:
+      clientID: mcpblade
+      scope: [ "read" ]
+`
+
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		assert.Fail(err.Error())
+		return
+	}
+
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) {
+		assert.Fail("expected validation errors")
+		return
+	}
+
+	assert.Equal(ValidationErrors{
+		{Field: "cacheRefershTTL", Message: "unknown field", Line: 2, Column: 1},
+		{Field: "vectr", Message: "unknown field", Line: 3, Column: 1},
+		{Field: "vector.embeding", Message: "unknown field", Line: 7, Column: 3},
+		{Field: "vector.embedding.modle", Message: "unknown field", Line: 11, Column: 5},
+		{Field: "mcpServers.remote.tls.insecur", Message: "unknown field", Line: 18, Column: 7},
+		{Field: "mcpServers.remote.oauth.scope", Message: "unknown field", Line: 21, Column: 7},
+	}, errs)
+}
+
+func TestMCPServerConfigValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := []MCPServerConfig{
+		{Transport: TransportTypeStdio, Command: "uvx", Environment: []string{"TZ=Asia/Taipei"}},
+		{Transport: TransportTypeSSE, URL: "http://localhost:8080/sse"},
+		{Transport: TransportTypeStreamableHTTP, URL: "${REMOTE_URL}"},
+		{Transport: TransportTypeStreamableHTTP, URL: "https://example.com/mcp", OAuth: &OAuthConfig{Scopes: []string{"read"}}},
+		{Transport: TransportTypeNATS, Subject: "edges.edge01.mcp", RestartPolicy: RestartPolicyAlways},
+	}
+
+	for _, config := range valid {
+		assert.NoError(config.Validate(), config.Transport)
+	}
+
+	invalid := map[string]MCPServerConfig{
+		"transport: is required":                               {Command: "uvx"},
+		"url: must be an http or https URL":                    {Transport: TransportTypeSSE, URL: "ftp://example.com"},
+		"command: not used by the streamable-http transport":   {Transport: TransportTypeStreamableHTTP, URL: "https://example.com/mcp", Command: "uvx"},
+		"headers: not used by the stdio transport":             {Transport: TransportTypeStdio, Command: "uvx", Headers: map[string]string{"X-Key": "key"}},
+		"tls: cert and key must be set together":               {Transport: TransportTypeSSE, URL: "https://example.com/sse", TLS: &TLSConfig{Cert: "cert.pem"}},
+		"oauth: not used by the nats transport":                {Transport: TransportTypeNATS, Subject: "edges.edge01.mcp", OAuth: &OAuthConfig{}},
+		"oauth.redirectURI: must be an https or localhost URL": {Transport: TransportTypeSSE, URL: "https://example.com/sse", OAuth: &OAuthConfig{RedirectURI: "http://example.com/callback"}},
+		"maxRestarts: must not be negative":                    {Transport: TransportTypeStdio, Command: "uvx", MaxRestarts: -1},
+	}
+
+	for expected, config := range invalid {
+		err := config.Validate()
+		assert.ErrorIs(err, ErrInvalidConfig)
+
+		if err != nil {
+			assert.Contains(err.Error(), expected)
+		}
+	}
+}
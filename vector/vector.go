@@ -3,10 +3,31 @@ package vector
 import "context"
 
 type Config struct {
-	Enabled    bool   `yaml:"enabled"`
-	Persistent bool   `yaml:"persistent"`
-	Path       string `yaml:"path"`
-	Collection string `yaml:"collection"`
+	Enabled    bool            `yaml:"enabled"`
+	Persistent bool            `yaml:"persistent"`
+	Path       string          `yaml:"path"`
+	Collection string          `yaml:"collection"`
+	Embedding  EmbeddingConfig `yaml:"embedding"`
+}
+
+type EmbeddingProvider string
+
+const (
+	EmbeddingProviderOpenAI       EmbeddingProvider = "openai"
+	EmbeddingProviderOpenAICompat EmbeddingProvider = "openai-compatible"
+	EmbeddingProviderOllama       EmbeddingProvider = "ollama"
+	EmbeddingProviderHash         EmbeddingProvider = "hash"
+)
+
+// EmbeddingConfig selects the model used to embed documents and queries.
+// Switching providers changes the embedding dimensions, so a persistent
+// collection has to be recreated (or renamed) afterwards.
+type EmbeddingConfig struct {
+	Provider   EmbeddingProvider `yaml:"provider"`
+	BaseURL    string            `yaml:"baseURL"`
+	Model      string            `yaml:"model"`
+	APIKey     string            `yaml:"apiKey"`
+	Dimensions int               `yaml:"dimensions"`
 }
 
 type VectorDB interface {
@@ -16,7 +37,10 @@ type VectorDB interface {
 type Collection interface {
 	AddDocument(ctx context.Context, doc Document) error
 	FindDocument(ctx context.Context, id string) (Document, error)
+	DeleteDocument(ctx context.Context, id string) error
+	DeleteWhere(ctx context.Context, where map[string]string) error
 	Query(ctx context.Context, query string, k int) ([]Document, error)
+	ListDocuments(ctx context.Context) ([]Document, error)
 }
 
 type Document struct {